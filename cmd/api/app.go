@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/ruanv123/acme-hotel-api/internal/background"
+	"github.com/ruanv123/acme-hotel-api/internal/config"
+	"github.com/ruanv123/acme-hotel-api/internal/database"
+	"github.com/ruanv123/acme-hotel-api/internal/logger"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// application owns the resources of the API and the order in which they
+// are started and released
+type application struct {
+	config   *config.Config
+	db       *gorm.DB
+	sqlDB    *sql.DB
+	migrator *database.Migrator
+	tasks    *background.Group
+	server   *http.Server
+	// shuttingDown makes /readyz fail so no new traffic is routed here
+	shuttingDown atomic.Bool
+}
+
+// newApplication connects to the database. The HTTP server is only built
+// by Run or Serve.
+func newApplication(cfg *config.Config) (*application, error) {
+	// inicializando a conexão com o banco
+	db, err := database.InitDB(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	// gerando a conexão com o banco
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying *sql.DB instance: %v", err)
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to load migrations: %v", err)
+	}
+
+	return &application{
+		config:   cfg,
+		db:       db,
+		sqlDB:    sqlDB,
+		migrator: migrator,
+		tasks:    background.NewGroup(),
+	}, nil
+}
+
+// Run serves the API on the configured port until ctx is cancelled, then shuts down
+func (app *application) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", ":"+app.config.Server.Port)
+	if err != nil {
+		app.Close()
+		return err
+	}
+
+	return app.Serve(ctx, listener)
+}
+
+// Serve serves the API on listener until ctx is cancelled or the server
+// fails, then shuts everything down. Every resource of the application is
+// released when it returns.
+func (app *application) Serve(ctx context.Context, listener net.Listener) error {
+	handler, err := app.routes()
+	if err != nil {
+		listener.Close()
+		app.Close()
+		return err
+	}
+
+	app.server = &http.Server{
+		Handler:      handler,
+		WriteTimeout: app.config.Server.WriteTimeout,
+		ReadTimeout:  app.config.Server.ReadTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- app.server.Serve(listener)
+	}()
+
+	logger.LogEvent(logrus.InfoLevel, "API started", logrus.Fields{
+		"addr": listener.Addr().String(),
+	})
+
+	select {
+	case err := <-serveErr:
+		// the server stopped on its own, so there is nothing to drain
+		return errors.Join(err, app.shutdown())
+	case <-ctx.Done():
+	}
+
+	logger.LogEvent(logrus.InfoLevel, "Shutting down", logrus.Fields{
+		"delay":   app.config.Server.ShutdownDelay.String(),
+		"timeout": app.config.Server.ShutdownTimeout.String(),
+	})
+
+	// keep serving while /readyz fails, so the load balancer stops sending
+	// traffic before the listener closes
+	app.shuttingDown.Store(true)
+	time.Sleep(app.config.Server.ShutdownDelay)
+
+	if err := app.shutdown(); err != nil {
+		return err
+	}
+
+	logger.LogEvent(logrus.InfoLevel, "API stopped", logrus.Fields{})
+	return nil
+}
+
+// shutdown marks the API as not ready and stops the server, letting the
+// in-flight requests finish, then waits for the background tasks they
+// started and finally closes the database. All of it shares one
+// shutdown timeout.
+func (app *application) shutdown() error {
+	app.shuttingDown.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.Server.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	if app.server != nil {
+		if err := app.server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("draining HTTP server: %v", err))
+			app.server.Close()
+		}
+	}
+
+	if err := app.tasks.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("waiting for background tasks: %v", err))
+	}
+
+	if err := app.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("closing database: %v", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close releases the database connections
+func (app *application) Close() error {
+	return app.sqlDB.Close()
+}
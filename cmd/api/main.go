@@ -1,117 +1,88 @@
 package main
 
 import (
+	"context"
 	"log"
-	"net/http"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
-	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
-	"github.com/rs/cors"
-	"github.com/ruanv123/acme-hotel-api/internal/api/handlers"
-	"github.com/ruanv123/acme-hotel-api/internal/database"
+	"github.com/ruanv123/acme-hotel-api/internal/config"
 	"github.com/ruanv123/acme-hotel-api/internal/logger"
-	"github.com/ruanv123/acme-hotel-api/internal/middleware"
-	"github.com/ruanv123/acme-hotel-api/internal/repository"
-	"github.com/ruanv123/acme-hotel-api/internal/service"
+	"github.com/ruanv123/acme-hotel-api/internal/mailer"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
-	// carregando as variáveis de ambiente
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: error loading .env file: %s\n", err)
+	// "api migrate up|down|status" manages the schema and exits; it only
+	// needs the database settings
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cfg, err := config.LoadDatabase()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		app, err := newApplication(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = runMigrate(app.migrator, os.Args[2:])
+		app.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	// inicializando a conexão com o banco
-	db, err := database.InitDB()
+	// carregando a configuração do ambiente, do .env e do CONFIG_FILE
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal(err)
 	}
 
-	// gerando a conexão com o banco
-	sqlDB, err := db.DB()
+	logger.LogEvent(logrus.InfoLevel, "Configuration loaded", logrus.Fields(cfg.Redacted()))
+
+	app, err := newApplication(cfg)
 	if err != nil {
-		log.Fatal("Failed to get underlying *sql.DB instance:", err)
+		log.Fatal(err)
 	}
 
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(25)
-	sqlDB.SetConnMaxLifetime(5 * time.Minute)
-
-	// instanciando os repositórios
-	userRepo := repository.NewUserRepository(db)
-
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is required")
+	if cfg.Database.MigrateOnBoot {
+		applied, err := app.migrator.Up(context.Background())
+		if err != nil {
+			app.Close()
+			log.Fatal("Failed to migrate database:", err)
+		}
+		for _, m := range applied {
+			logger.LogEvent(logrus.InfoLevel, "Migration applied", logrus.Fields{
+				"version": m.Version,
+				"name":    m.Name,
+			})
+		}
 	}
 
-	authService := service.NewAuthService(
-		userRepo,
-		jwtSecret,
-	)
-
-	authHandler := handlers.NewAuthHandler(authService)
-
-	router := mux.NewRouter()
-	router.Use(middleware.LoggingMiddleware)
-
-	// public routes
-	router.HandleFunc("/auth/register", authHandler.Register).Methods("POST")
-	router.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
+	// SIGINT and SIGTERM start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// API routes (protected)
-	apiRouter := router.PathPrefix("/api/v1").Subrouter()
-	apiRouter.Use(middleware.AuthMiddleware(authService))
-
-	// user routes
-	apiRouter.HandleFunc("/me", authHandler.CheckUser).Methods("GET")
-
-	corsMiddleware := cors.New(cors.Options{
-		AllowedOrigins: []string{"*"}, // Allow all origins
-		AllowedMethods: []string{
-			http.MethodGet,
-			http.MethodPost,
-			http.MethodPut,
-			http.MethodPatch,
-			http.MethodDelete,
-			http.MethodOptions,
-		},
-		AllowedHeaders: []string{
-			"Accept",
-			"Authorization",
-			"Content-Type",
-			"X-CSRF-Token",
-			"X-API-Key",
-			"*", // Allow all headers
-		},
-		ExposedHeaders: []string{
-			"Link",
-		},
-		AllowCredentials: false, // Must be false when using AllowedOrigins: ["*"]
-		MaxAge:           300,
-	})
-
-	srv := &http.Server{
-		Handler:      corsMiddleware.Handler(router),
-		Addr:         ":" + getPort(),
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
+	if err := app.Run(ctx); err != nil {
+		log.Fatal(err)
 	}
-
-	// Start server
-	logger.LogEvent(logrus.InfoLevel, "API started", logrus.Fields{
-		"port": "8080",
-	})
-	log.Fatal(srv.ListenAndServe())
 }
 
-func getPort() string {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "5050"
+// newMailer picks the mail sender from the mail driver: "smtp" delivers
+// through the SMTP server, "log" appends the messages to a file
+func newMailer(cfg config.MailConfig) (mailer.Mailer, error) {
+	if cfg.Driver == "smtp" {
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		}), nil
 	}
-	return port
+
+	return mailer.NewFileMailer(cfg.LogPath)
 }
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ruanv123/acme-hotel-api/internal/api/handlers"
+	"github.com/ruanv123/acme-hotel-api/internal/logger"
+	"github.com/ruanv123/acme-hotel-api/internal/middleware"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// routes wires the repositories, services and handlers together and
+// returns the HTTP handler of the API
+func (app *application) routes() (http.Handler, error) {
+	// instanciando os repositórios
+	userRepo := repository.NewUserRepository(app.db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(app.db)
+	guestRepo := repository.NewGuestRepository(app.db)
+	roomRepo := repository.NewRoomRepository(app.db)
+	reservationRepo := repository.NewReservationRepository(app.db)
+	paymentRepo := repository.NewPaymentRepository(app.db)
+	roleRepo := repository.NewRoleRepository(app.db)
+	passwordResetRepo := repository.NewPasswordResetRepository(app.db)
+	invitationRepo := repository.NewInvitationRepository(app.db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(app.db)
+	apiKeyRepo := repository.NewAPIKeyRepository(app.db)
+	loginFailureRepo := repository.NewLoginFailureRepository(app.db)
+
+	authService := service.NewAuthService(
+		userRepo,
+		refreshTokenRepo,
+		invitationRepo,
+		recoveryCodeRepo,
+		loginFailureRepo,
+		roleRepo,
+		service.AuthSettings{
+			JWTSecret:       app.config.Auth.JWTSecret,
+			AccessTokenTTL:  app.config.Auth.AccessTokenTTL,
+			RefreshTokenTTL: app.config.Auth.RefreshTokenTTL,
+			InviteOnly:      app.config.Auth.RegistrationMode == "invite",
+		},
+	)
+
+	// the failed logins of unknown e-mails are kept in the database, so the
+	// ones that can't lock anything anymore are deleted now and then
+	app.tasks.Every(time.Hour, time.Minute, func(ctx context.Context) {
+		if err := authService.PruneLoginFailures(ctx); err != nil {
+			logger.LogEvent(logrus.ErrorLevel, "Failed to prune login failures", logrus.Fields{
+				"error": err.Error(),
+			})
+		}
+	})
+
+	twoFactorService := service.NewTwoFactorService(userRepo, recoveryCodeRepo)
+
+	mail, err := newMailer(app.config.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("configuring mailer: %v", err)
+	}
+
+	passwordResetService := service.NewPasswordResetService(
+		userRepo,
+		passwordResetRepo,
+		refreshTokenRepo,
+		mail,
+		app.tasks,
+		app.config.Mail.PasswordResetURL,
+	)
+
+	invitationService := service.NewInvitationService(
+		invitationRepo,
+		userRepo,
+		roleRepo,
+		mail,
+		app.tasks,
+		app.config.Mail.InvitationURL,
+	)
+
+	roleService := service.NewRoleService(roleRepo, userRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, roleRepo, userRepo)
+	guestService := service.NewGuestService(guestRepo)
+	roomService := service.NewRoomService(roomRepo)
+	reservationService := service.NewReservationService(reservationRepo, guestRepo, roomRepo)
+	paymentService := service.NewPaymentService(paymentRepo, reservationRepo)
+
+	authHandler := handlers.NewAuthHandler(authService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+	adminHandler := handlers.NewAdminHandler(authService, roleService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	guestHandler := handlers.NewGuestHandler(guestService)
+	roomHandler := handlers.NewRoomHandler(roomService)
+	reservationHandler := handlers.NewReservationHandler(reservationService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	healthHandler := handlers.NewHealthHandler(app.sqlDB, app.migrator, app.shuttingDown.Load)
+
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(handlers.NotFound)
+	router.Use(middleware.LoggingMiddleware)
+
+	// liveness and readiness probes
+	router.HandleFunc("/healthz", healthHandler.Live).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.Ready).Methods("GET")
+
+	// public routes
+	router.HandleFunc("/auth/register", authHandler.Register).Methods("POST")
+	router.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
+	router.HandleFunc("/auth/login/2fa", authHandler.LoginTwoFactor).Methods("POST")
+	router.HandleFunc("/auth/refresh", authHandler.Refresh).Methods("POST")
+	router.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST")
+	router.HandleFunc("/auth/forgot-password", passwordResetHandler.ForgotPassword).Methods("POST")
+	router.HandleFunc("/auth/reset-password", passwordResetHandler.ResetPassword).Methods("POST")
+
+	// API routes (protected)
+	apiRouter := router.PathPrefix("/api/v1").Subrouter()
+	apiRouter.Use(middleware.AuthMiddleware(authService, apiKeyService))
+
+	// user routes, not available to API keys
+	meRouter := apiRouter.PathPrefix("/me").Subrouter()
+	meRouter.Use(middleware.SessionOnly)
+	meRouter.HandleFunc("", authHandler.CheckUser).Methods("GET")
+	meRouter.HandleFunc("", authHandler.UpdateUser).Methods("PUT")
+	meRouter.HandleFunc("/2fa/enroll", twoFactorHandler.Enroll).Methods("POST")
+	meRouter.HandleFunc("/2fa/activate", twoFactorHandler.Activate).Methods("POST")
+	meRouter.HandleFunc("/2fa/disable", twoFactorHandler.Disable).Methods("POST")
+	meRouter.HandleFunc("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes).Methods("POST")
+
+	// with TWO_FACTOR_REQUIRED=true everything but the user's own account
+	// needs two-factor authentication turned on
+	staffRouter := apiRouter.NewRoute().Subrouter()
+	if app.config.Auth.TwoFactorRequired {
+		staffRouter.Use(middleware.RequireTwoFactor)
+	}
+
+	// can only lets through users whose role grants permission
+	can := func(permission string, handler http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(roleService, permission)(handler)
+	}
+
+	// guest routes
+	staffRouter.Handle("/guests", can(models.PermissionGuestsRead, guestHandler.List)).Methods("GET")
+	staffRouter.Handle("/guests", can(models.PermissionGuestsWrite, guestHandler.Create)).Methods("POST")
+	staffRouter.Handle("/guests/{id}", can(models.PermissionGuestsRead, guestHandler.Get)).Methods("GET")
+	staffRouter.Handle("/guests/{id}", can(models.PermissionGuestsWrite, guestHandler.Update)).Methods("PUT")
+	staffRouter.Handle("/guests/{id}", can(models.PermissionGuestsWrite, guestHandler.Delete)).Methods("DELETE")
+
+	// availability search
+	staffRouter.Handle("/availability", can(models.PermissionRoomsRead, roomHandler.Availability)).Methods("GET")
+
+	// housekeeping
+	staffRouter.Handle("/housekeeping/rooms/{id}/cleaned", can(models.PermissionHousekeeping, roomHandler.MarkCleaned)).Methods("POST")
+
+	// reservation routes
+	staffRouter.Handle("/reservations", can(models.PermissionReservationsRead, reservationHandler.List)).Methods("GET")
+	staffRouter.Handle("/reservations", can(models.PermissionReservationsWrite, reservationHandler.Create)).Methods("POST")
+	staffRouter.Handle("/reservations/{id}", can(models.PermissionReservationsRead, reservationHandler.Get)).Methods("GET")
+	staffRouter.Handle("/reservations/{id}/history", can(models.PermissionReservationsRead, reservationHandler.History)).Methods("GET")
+	staffRouter.Handle("/reservations/{id}/confirm", can(models.PermissionReservationsWrite, reservationHandler.Confirm)).Methods("POST")
+	staffRouter.Handle("/reservations/{id}/cancel", can(models.PermissionReservationsWrite, reservationHandler.Cancel)).Methods("POST")
+	staffRouter.Handle("/reservations/{id}/no-show", can(models.PermissionReservationsWrite, reservationHandler.NoShow)).Methods("POST")
+	staffRouter.Handle("/reservations/{id}/check-in", can(models.PermissionReservationsWrite, reservationHandler.CheckIn)).Methods("POST")
+	staffRouter.Handle("/reservations/{id}/check-out", can(models.PermissionReservationsWrite, reservationHandler.CheckOut)).Methods("POST")
+	staffRouter.Handle("/reservations/{id}/payments", can(models.PermissionPaymentsRead, paymentHandler.List)).Methods("GET")
+	staffRouter.Handle("/reservations/{id}/payments", can(models.PermissionPaymentsWrite, paymentHandler.Create)).Methods("POST")
+
+	// user administration
+	adminRouter := staffRouter.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(middleware.RequirePermission(roleService, models.PermissionUsersManage))
+	adminRouter.HandleFunc("/users/{id}/enable", adminHandler.EnableUser).Methods("POST")
+	adminRouter.HandleFunc("/users/{id}/disable", adminHandler.DisableUser).Methods("POST")
+	adminRouter.HandleFunc("/users/{id}/unlock", adminHandler.UnlockUser).Methods("POST")
+	adminRouter.HandleFunc("/users/{id}/role", adminHandler.AssignRole).Methods("PUT")
+	adminRouter.HandleFunc("/roles", adminHandler.ListRoles).Methods("GET")
+	adminRouter.HandleFunc("/roles", adminHandler.CreateRole).Methods("POST")
+	adminRouter.HandleFunc("/roles/{name}/permissions", adminHandler.SetRolePermissions).Methods("PUT")
+	adminRouter.HandleFunc("/permissions", adminHandler.ListPermissions).Methods("GET")
+	adminRouter.HandleFunc("/invitations", invitationHandler.List).Methods("GET")
+	adminRouter.HandleFunc("/invitations", invitationHandler.Create).Methods("POST")
+	adminRouter.HandleFunc("/invitations/{id}", invitationHandler.Revoke).Methods("DELETE")
+	adminRouter.HandleFunc("/api-keys", apiKeyHandler.List).Methods("GET")
+	adminRouter.HandleFunc("/api-keys", apiKeyHandler.Create).Methods("POST")
+	adminRouter.HandleFunc("/api-keys/{id}", apiKeyHandler.Revoke).Methods("DELETE")
+
+	// room inventory
+	roomRouter := staffRouter.PathPrefix("/rooms").Subrouter()
+	roomRouter.Use(middleware.RequirePermission(roleService, models.PermissionRoomsManage))
+	roomRouter.HandleFunc("", roomHandler.List).Methods("GET")
+	roomRouter.HandleFunc("", roomHandler.Create).Methods("POST")
+	roomRouter.HandleFunc("/{id}", roomHandler.Get).Methods("GET")
+	roomRouter.HandleFunc("/{id}", roomHandler.Update).Methods("PUT")
+	roomRouter.HandleFunc("/{id}", roomHandler.Retire).Methods("DELETE")
+
+	cors := middleware.CORS(app.config.CORS)
+	realIP := middleware.RealIP(app.config.Server.TrustedProxies)
+
+	return cors(middleware.RequestID(realIP(router))), nil
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+// AdminHandler serves the user management endpoints under /api/v1/admin
+type AdminHandler struct {
+	authService service.AuthService
+	roleService service.RoleService
+}
+
+func NewAdminHandler(authService service.AuthService, roleService service.RoleService) *AdminHandler {
+	return &AdminHandler{
+		authService: authService,
+		roleService: roleService,
+	}
+}
+
+type userStatusResponse struct {
+	ID     string `json:"id"`
+	Status bool   `json:"status"`
+}
+
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("user"))
+		return
+	}
+
+	if err := h.authService.GrantAccess(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userStatusResponse{ID: id.String(), Status: true})
+}
+
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("user"))
+		return
+	}
+
+	admin, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	if err := h.authService.RevokeAccess(r.Context(), admin.ID, id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userStatusResponse{ID: id.String(), Status: false})
+}
+
+type userLockResponse struct {
+	ID     string `json:"id"`
+	Locked bool   `json:"locked"`
+}
+
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("user"))
+		return
+	}
+
+	if err := h.authService.UnlockAccount(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userLockResponse{ID: id.String(), Locked: false})
+}
+
+type roleRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+type rolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type assignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type userRoleResponse struct {
+	ID   string `json:"id"`
+	Role string `json:"role"`
+}
+
+func (h *AdminHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roleService.ListRoles(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, roles)
+}
+
+func (h *AdminHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.roleService.ListPermissions(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, permissions)
+}
+
+func (h *AdminHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	role, err := h.roleService.CreateRole(r.Context(), service.RoleInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, role)
+}
+
+func (h *AdminHandler) SetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	var req rolePermissionsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	role, err := h.roleService.SetRolePermissions(r.Context(), mux.Vars(r)["name"], req.Permissions)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, role)
+}
+
+func (h *AdminHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("user"))
+		return
+	}
+
+	var req assignRoleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	admin, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	if err := h.roleService.AssignRole(r.Context(), admin.ID, id, req.Role); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userRoleResponse{ID: id.String(), Role: req.Role})
+}
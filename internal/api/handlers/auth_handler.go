@@ -1,9 +1,11 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/ruanv123/acme-hotel-api/internal/service"
 )
@@ -19,28 +21,55 @@ func NewAuthHandler(authService service.AuthService) *AuthHandler {
 }
 
 type registrationRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name            string `json:"name" validate:"required,max=255"`
+	Email           string `json:"email" validate:"required,email,max=255"`
+	Password        string `json:"password" validate:"required,max=72"`
+	InvitationToken string `json:"invitation_token,omitempty"`
 }
 
 type registrationResponse struct {
 	User struct {
 		ID    string `json:"id"`
 		Email string `json:"email"`
+		Role  string `json:"role"`
 	}
 	Error string `json:"error,omitempty"`
 }
 
 type loginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 type authResponse struct {
-	Token string `json:"token,omitempty"`
-	Admin bool   `json:"admin"`
-	Error string `json:"error,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresAt    int64  `json:"expires_at,omitempty"`
+	Admin        bool   `json:"admin"`
+	Error        string `json:"error,omitempty"`
+}
+
+// twoFactorChallengeResponse asks the client to finish the login at
+// /auth/login/2fa
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresAt         int64  `json:"expires_at"`
+}
+
+type twoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type refreshResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresAt    int64  `json:"expires_at"`
 }
 
 type validateResponse struct {
@@ -48,56 +77,138 @@ type validateResponse struct {
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req registrationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	user, err := h.authService.Register(r.Context(), req.Email, req.Password, req.Name)
+	user, err := h.authService.Register(r.Context(), service.RegisterInput{
+		Name:            req.Name,
+		Email:           req.Email,
+		Password:        req.Password,
+		InvitationToken: req.InvitationToken,
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, r, err)
 		return
 	}
 
 	resp := registrationResponse{}
 	resp.User.ID = user.ID.String()
 	resp.User.Email = user.Email
+	resp.User.Role = user.Role
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	writeJSON(w, http.StatusCreated, resp)
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	var req loginRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	var req loginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	tokens, isAdmin, err := h.authService.Login(r.Context(), req.Email, req.Password, clientIP(r))
+	if err != nil {
+		var challenge *service.TwoFactorRequiredError
+		if errors.As(err, &challenge) {
+			writeJSON(w, http.StatusOK, twoFactorChallengeResponse{
+				TwoFactorRequired: true,
+				ChallengeToken:    challenge.ChallengeToken,
+				ExpiresAt:         challenge.ExpiresAt.Unix(),
+			})
+			return
+		}
+		writeLoginError(w, r, err)
+		return
+	}
+
+	resp := authResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.AccessTokenExpiresAt.Unix(),
+		Admin:        isAdmin,
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// LoginTwoFactor completes a login with the code of the authenticator app
+// or a recovery code
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req twoFactorLoginRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	token, isAdmin, err := h.authService.Login(r.Context(), req.Email, req.Password)
+	tokens, isAdmin, err := h.authService.VerifyTwoFactor(r.Context(), req.ChallengeToken, req.Code, clientIP(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeLoginError(w, r, err)
 		return
 	}
 
-	resp := authResponse{
-		Token: token,
-		Admin: isAdmin,
+	writeJSON(w, http.StatusOK, authResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.AccessTokenExpiresAt.Unix(),
+		Admin:        isAdmin,
+	})
+}
+
+// writeLoginError tells throttled clients when to retry
+func writeLoginError(w http.ResponseWriter, r *http.Request, err error) {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		seconds := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
 	}
+	writeServiceError(w, r, err)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+// clientIP is the address the request came from, without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	tokens, err := h.authService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, refreshResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.AccessTokenExpiresAt.Unix(),
+	})
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.authService.Logout(r.Context(), req.RefreshToken); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 type checkResponse struct {
@@ -110,88 +221,67 @@ type checkResponse struct {
 func (h *AuthHandler) CheckUser(w http.ResponseWriter, r *http.Request) {
 	user, ok := service.UserFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Error processing your request", http.StatusForbidden)
+		writeServiceError(w, r, errUnauthenticated)
 		return
 	}
 
-	fmt.Print("User API keys fetched successfully.")
-
 	resp := checkResponse{}
 
 	resp.Name = user.Name
 	resp.Email = user.Email
 	resp.Role = user.Role
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
-
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (h *AuthHandler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 	resp := validateResponse{Validate: "Token valid"}
-	json.NewEncoder(w).Encode(resp)
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // updateUserRequest represents the structure of a user update request
 type updateUserRequest struct {
-	Name     string `json:"name,omitempty"`
-	Password string `json:"password,omitempty"`
+	Name            string `json:"name,omitempty" validate:"max=255"`
+	CurrentPassword string `json:"current_password,omitempty"`
+	Password        string `json:"password,omitempty" validate:"max=72"`
 }
 
 // updateUserResponse represents the structure of a user update response
 type updateUserResponse struct {
 	Message string `json:"message"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
 	Error   string `json:"error,omitempty"`
 }
 
 func (h *AuthHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req updateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
 	user, ok := service.UserFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeServiceError(w, r, errUnauthenticated)
 		return
 	}
+	sessionID, _ := service.SessionFromContext(r.Context())
 
-	err := h.authService.UpdateUser(r.Context(), user.ID, req.Name, req.Password)
+	updated, err := h.authService.UpdateUser(r.Context(), user.ID, sessionID, service.UpdateUserInput{
+		Name:            req.Name,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.Password,
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
-	resp := updateUserResponse{Message: "User updated successfully"}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
-}
-
-func (h *AuthHandler) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		tokenString := r.Header.Get("Authorization")
-		if tokenString == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-			tokenString = tokenString[7:]
-		}
-
-		user, err := h.authService.VerifyToken(tokenString)
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		ctx := service.WithUserContext(r.Context(), user)
-		next.ServeHTTP(w, r.WithContext(ctx))
+	resp := updateUserResponse{
+		Message: "User updated successfully",
+		Name:    updated.Name,
+		Email:   updated.Email,
 	}
+	writeJSON(w, http.StatusOK, resp)
 }
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+// dateLayout is the format used for calendar dates in requests
+const dateLayout = "2006-01-02"
+
+type GuestHandler struct {
+	guestService service.GuestService
+}
+
+func NewGuestHandler(guestService service.GuestService) *GuestHandler {
+	return &GuestHandler{
+		guestService: guestService,
+	}
+}
+
+type guestRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	Cpf         string `json:"cpf" validate:"required"`
+	DataNasc    string `json:"data_nasc" validate:"required,date"`
+	Telefone    string `json:"telefone" validate:"required,max=15"`
+	Email       string `json:"email" validate:"required,email,max=255"`
+	Observacoes string `json:"observacoes"`
+}
+
+func (req guestRequest) toInput() (service.GuestInput, error) {
+	input := service.GuestInput{
+		Name:        req.Name,
+		Cpf:         req.Cpf,
+		Telefone:    req.Telefone,
+		Email:       req.Email,
+		Observacoes: req.Observacoes,
+	}
+
+	if req.DataNasc != "" {
+		dataNasc, err := time.Parse(dateLayout, req.DataNasc)
+		if err != nil {
+			return input, errors.NewValidationError("data_nasc", "must be a date in YYYY-MM-DD format")
+		}
+		input.DataNasc = dataNasc
+	}
+
+	return input, nil
+}
+
+func (h *GuestHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req guestRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	input, err := req.toInput()
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	guest, err := h.guestService.Create(r.Context(), input)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, guest)
+}
+
+func (h *GuestHandler) List(w http.ResponseWriter, r *http.Request) {
+	guests, err := h.guestService.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, guests)
+}
+
+func (h *GuestHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("guest"))
+		return
+	}
+
+	guest, err := h.guestService.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, guest)
+}
+
+func (h *GuestHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("guest"))
+		return
+	}
+
+	var req guestRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	input, err := req.toInput()
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	guest, err := h.guestService.Update(r.Context(), id, input)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, guest)
+}
+
+func (h *GuestHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("guest"))
+		return
+	}
+
+	if err := h.guestService.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ruanv123/acme-hotel-api/internal/database"
+	"github.com/ruanv123/acme-hotel-api/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// readinessTimeout bounds each dependency check of Ready
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db           *sql.DB
+	migrator     *database.Migrator
+	shuttingDown func() bool
+}
+
+// NewHealthHandler reports the API as not ready while shuttingDown returns
+// true
+func NewHealthHandler(db *sql.DB, migrator *database.Migrator, shuttingDown func() bool) *HealthHandler {
+	return &HealthHandler{
+		db:           db,
+		migrator:     migrator,
+		shuttingDown: shuttingDown,
+	}
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+type checkResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Version   *int    `json:"version,omitempty"`
+	Expected  *int    `json:"expected_version,omitempty"`
+}
+
+const (
+	statusOK           = "ok"
+	statusUnavailable  = "unavailable"
+	statusShuttingDown = "shutting_down"
+)
+
+// Live tells whether the process is up. It doesn't look at any dependency,
+// so a database outage doesn't get the API restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: statusOK})
+}
+
+// Ready tells whether the API can serve requests: the database answers and
+// its schema is at the version this build expects
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown() {
+		writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: statusShuttingDown})
+		return
+	}
+
+	response := healthResponse{
+		Status: statusOK,
+		Checks: map[string]checkResult{
+			"database":   h.check(r.Context(), "database", h.pingDatabase),
+			"migrations": h.checkMigrations(r.Context()),
+		},
+	}
+
+	status := http.StatusOK
+	for _, result := range response.Checks {
+		if result.Status != statusOK {
+			response.Status = statusUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	writeJSON(w, status, response)
+}
+
+func (h *HealthHandler) pingDatabase(ctx context.Context) error {
+	return h.db.PingContext(ctx)
+}
+
+func (h *HealthHandler) checkMigrations(ctx context.Context) checkResult {
+	expected := h.migrator.LatestVersion()
+	var version *int
+
+	result := h.check(ctx, "migrations", func(ctx context.Context) error {
+		current, err := h.migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		version = &current
+
+		if current != expected {
+			return checkFailure(fmt.Sprintf("schema is at version %d, expected %d", current, expected))
+		}
+		return nil
+	})
+	result.Version = version
+	result.Expected = &expected
+
+	return result
+}
+
+// checkFailure is a check error that is safe to show to the client; any
+// other error is only logged, since it may name hosts or users
+type checkFailure string
+
+func (f checkFailure) Error() string {
+	return string(f)
+}
+
+// check runs fn with readinessTimeout and times it
+func (h *HealthHandler) check(ctx context.Context, name string, fn func(ctx context.Context) error) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+	result := checkResult{
+		Status:    statusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		logger.LogEvent(logrus.WarnLevel, "Readiness check failed", logrus.Fields{
+			"check": name,
+			"error": err.Error(),
+		})
+
+		result.Status = statusUnavailable
+		result.Error = name + " check failed"
+		if failure, ok := err.(checkFailure); ok {
+			result.Error = failure.Error()
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+// InvitationHandler serves the staff invitations under /api/v1/admin
+type InvitationHandler struct {
+	invitationService service.InvitationService
+}
+
+func NewInvitationHandler(invitationService service.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+type invitationRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  string `json:"role" validate:"required"`
+}
+
+type invitationResponse struct {
+	*models.Invitation
+	URL string `json:"url"`
+}
+
+func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req invitationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	admin, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	invitation, link, err := h.invitationService.Create(r.Context(), admin.ID, service.InvitationInput{
+		Email: req.Email,
+		Role:  req.Role,
+	})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, invitationResponse{Invitation: invitation, URL: link})
+}
+
+func (h *InvitationHandler) List(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.invitationService.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, invitations)
+}
+
+func (h *InvitationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("invitation"))
+		return
+	}
+
+	if err := h.invitationService.Revoke(r.Context(), id); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+type PaymentHandler struct {
+	paymentService service.PaymentService
+}
+
+func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+type paymentRequest struct {
+	AmountPaid    money.Amount `json:"amount_paid" validate:"required"`
+	Currency      string       `json:"currency,omitempty"`
+	PaymentMethod string       `json:"payment_method" validate:"required"`
+	PaymentDate   string       `json:"payment_date,omitempty" validate:"date"`
+}
+
+type paymentSummaryResponse struct {
+	ReservationID uuid.UUID        `json:"reservation_id"`
+	Currency      string           `json:"currency"`
+	TotalAmount   money.Amount     `json:"total_amount"`
+	AmountPaid    money.Amount     `json:"amount_paid"`
+	Balance       money.Amount     `json:"balance"`
+	Payments      []models.Payment `json:"payments"`
+}
+
+func (h *PaymentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("reservation"))
+		return
+	}
+
+	var req paymentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	input := service.PaymentInput{
+		AmountPaid:    req.AmountPaid,
+		Currency:      req.Currency,
+		PaymentMethod: req.PaymentMethod,
+	}
+	if req.PaymentDate != "" {
+		input.PaymentDate, err = time.Parse(dateLayout, req.PaymentDate)
+		if err != nil {
+			writeServiceError(w, r, errors.NewValidationError("payment_date", "must be a date in YYYY-MM-DD format"))
+			return
+		}
+	}
+
+	payment, err := h.paymentService.Record(r.Context(), reservationID, input)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, payment)
+}
+
+func (h *PaymentHandler) List(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("reservation"))
+		return
+	}
+
+	summary, err := h.paymentService.Summary(r.Context(), reservationID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, paymentSummaryResponse{
+		ReservationID: summary.ReservationID,
+		Currency:      summary.Currency,
+		TotalAmount:   summary.TotalAmount,
+		AmountPaid:    summary.AmountPaid,
+		Balance:       summary.Balance,
+		Payments:      summary.Payments,
+	})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"github.com/ruanv123/acme-hotel-api/internal/validation"
+)
+
+// maxBodyBytes caps the size of JSON request bodies
+const maxBodyBytes = 1 << 20
+
+var (
+	errEmptyBody     = errors.WithCode(errors.ErrInvalidInput, "INVALID_BODY", "request body is empty")
+	errBodyTooLarge  = errors.WithCode(errors.ErrPayloadTooLarge, errors.CodePayloadTooLarge, fmt.Sprintf("request body must be at most %d bytes", maxBodyBytes))
+	errTrailingData  = errors.WithCode(errors.ErrInvalidInput, "INVALID_BODY", "request body must contain a single JSON object")
+	errMalformedBody = errors.WithCode(errors.ErrInvalidInput, "INVALID_BODY", "request body is not valid JSON")
+)
+
+// decodeJSON reads the JSON object in the request body into dst, rejecting
+// oversized bodies and unknown fields, and then checks the `validate` tags
+// of dst. The errors it returns are ready for writeServiceError.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			return errBodyTooLarge
+		}
+		return errMalformedBody
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err, body, reflect.TypeOf(dst))
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errTrailingData
+	}
+
+	return validation.Struct(dst)
+}
+
+// decodeError turns the error of a json.Decoder decoding body into a value
+// of type t into one a client can act on
+func decodeError(err error, body []byte, t reflect.Type) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case stderrors.Is(err, io.EOF):
+		return errEmptyBody
+	case stderrors.As(err, &syntaxErr), stderrors.Is(err, io.ErrUnexpectedEOF):
+		return errMalformedBody
+	case stderrors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return errors.WithCode(errors.ErrInvalidInput, "INVALID_BODY", "request body must be a JSON object")
+		}
+		return errors.NewValidationError(typeErr.Field, "must be "+jsonKind(typeErr.Type.Kind().String()))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return errors.NewValidationError(field, "is not a known field")
+	}
+
+	// errors from custom UnmarshalJSON methods, such as a malformed amount,
+	// carry no field and their text isn't meant for clients
+	if field, fieldType, ok := invalidField(body, t, ""); ok {
+		return errors.NewValidationError(field, valueKind(fieldType))
+	}
+	return errMalformedBody
+}
+
+// invalidField finds the first field of the struct type t, or of the structs
+// nested in it, whose value in body doesn't decode on its own, returning
+// its JSON path and type. path prefixes the returned path.
+func invalidField(body []byte, t reflect.Type, path string) (string, reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", nil, false
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(body, &values); err != nil {
+		return "", nil, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		raw, ok := lookupField(values, name)
+		if !ok {
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+
+		if json.Unmarshal(raw, reflect.New(field.Type).Interface()) == nil {
+			continue
+		}
+		if nested, nestedType, ok := invalidField(raw, field.Type, fieldPath); ok {
+			return nested, nestedType, true
+		}
+		return fieldPath, field.Type, true
+	}
+
+	return "", nil, false
+}
+
+// lookupField matches JSON keys to field names case-insensitively, as
+// encoding/json does
+func lookupField(values map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := values[name]; ok {
+		return raw, true
+	}
+	for key, raw := range values {
+		if strings.EqualFold(key, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+// valueKind describes the values a type with its own JSON decoding accepts
+func valueKind(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(money.Amount(0)):
+		return "must be an amount with at most two decimal places, such as 150.50"
+	case reflect.TypeOf(time.Time{}):
+		return "must be a date and time such as 2024-01-31T12:00:00Z"
+	default:
+		return "has an invalid value"
+	}
+}
+
+// jsonKind describes a Go kind in terms of the JSON value it decodes from
+func jsonKind(kind string) string {
+	switch {
+	case kind == "string":
+		return "a string"
+	case kind == "bool":
+		return "a boolean"
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"):
+		return "an integer"
+	case strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "slice", kind == "array":
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+)
+
+type errorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Fields  []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"fields"`
+	} `json:"error"`
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	type item struct {
+		Price money.Amount `json:"price"`
+	}
+	type request struct {
+		Name    string       `json:"name"`
+		Amount  money.Amount `json:"amount"`
+		Item    item         `json:"item"`
+		Pointer *item        `json:"pointer"`
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantField   string
+		wantMessage string
+	}{
+		{name: "valid", body: `{"name":"a","amount":"10.50","item":{"price":1}}`, wantStatus: http.StatusOK},
+		{name: "malformed amount", body: `{"amount":"abc"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "VALIDATION_FAILED", wantField: "amount", wantMessage: "must be an amount with at most two decimal places, such as 150.50"},
+		{name: "amount with three decimals", body: `{"amount":1.505}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "VALIDATION_FAILED", wantField: "amount", wantMessage: "must be an amount with at most two decimal places, such as 150.50"},
+		{name: "malformed nested amount", body: `{"name":"a","item":{"price":"1,50"}}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "VALIDATION_FAILED", wantField: "item.price"},
+		{name: "malformed amount behind a pointer", body: `{"pointer":{"price":"x"}}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "VALIDATION_FAILED", wantField: "pointer.price"},
+		{name: "key in another case", body: `{"AMOUNT":"abc"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "VALIDATION_FAILED", wantField: "amount"},
+		{name: "wrong type", body: `{"name":1}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "VALIDATION_FAILED", wantField: "name", wantMessage: "must be a string"},
+		{name: "unknown field", body: `{"nickname":"a"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: "VALIDATION_FAILED", wantField: "nickname"},
+		{name: "empty body", body: ``, wantStatus: http.StatusBadRequest, wantCode: "INVALID_BODY"},
+		{name: "not JSON", body: `{"name":`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_BODY"},
+		{name: "two objects", body: `{} {}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_BODY"},
+		{name: "too large", body: `{"name":"` + strings.Repeat("a", maxBodyBytes) + `"}`, wantStatus: http.StatusRequestEntityTooLarge, wantCode: "PAYLOAD_TOO_LARGE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			var dst request
+			if err := decodeJSON(w, r, &dst); err != nil {
+				writeServiceError(w, r, err)
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d; want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			assertErrorResponse(t, w, tt.wantCode, tt.wantField, tt.wantMessage)
+		})
+	}
+}
+
+// TestPaymentCreateMalformedAmount checks that a malformed amount is reported
+// on its field without the decoder's own error text
+func TestPaymentCreateMalformedAmount(t *testing.T) {
+	handler := NewPaymentHandler(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount_paid":"abc","payment_method":"pix"}`))
+	r = mux.SetURLVars(r, map[string]string{"id": uuid.NewString()})
+	handler.Create(w, r)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d; want %d (body %s)", w.Code, http.StatusUnprocessableEntity, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "invalid amount") {
+		t.Errorf("the response echoes the decoder error: %s", w.Body)
+	}
+	assertErrorResponse(t, w, "VALIDATION_FAILED", "amount_paid", "must be an amount with at most two decimal places, such as 150.50")
+}
+
+// assertErrorResponse checks the error envelope in w; an empty field or
+// message isn't checked
+func assertErrorResponse(t *testing.T, w *httptest.ResponseRecorder, code, field, message string) {
+	t.Helper()
+
+	var resp errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response %s: %v", w.Body, err)
+	}
+	if resp.Error.Code != code {
+		t.Errorf("code = %s; want %s", resp.Error.Code, code)
+	}
+	if field == "" {
+		return
+	}
+	if len(resp.Error.Fields) != 1 {
+		t.Fatalf("fields = %+v; want one for %s", resp.Error.Fields, field)
+	}
+	if got := resp.Error.Fields[0]; got.Field != field || (message != "" && got.Message != message) {
+		t.Errorf("field error = %+v; want %s: %s", got, field, message)
+	}
+}
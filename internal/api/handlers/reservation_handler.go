@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+type ReservationHandler struct {
+	reservationService service.ReservationService
+}
+
+func NewReservationHandler(reservationService service.ReservationService) *ReservationHandler {
+	return &ReservationHandler{
+		reservationService: reservationService,
+	}
+}
+
+type reservationRequest struct {
+	GuestID      string `json:"guest_id" validate:"required,uuid"`
+	RoomID       string `json:"room_id" validate:"required,uuid"`
+	CheckInDate  string `json:"check_in_date" validate:"required,date"`
+	CheckOutDate string `json:"check_out_date" validate:"required,date"`
+}
+
+func (req reservationRequest) toInput() (service.ReservationInput, error) {
+	var input service.ReservationInput
+
+	guestID, err := uuid.Parse(req.GuestID)
+	if err != nil {
+		return input, errors.NewValidationError("guest_id", "must be a valid UUID")
+	}
+	input.GuestID = guestID
+
+	roomID, err := uuid.Parse(req.RoomID)
+	if err != nil {
+		return input, errors.NewValidationError("room_id", "must be a valid UUID")
+	}
+	input.RoomID = roomID
+
+	checkIn, err := time.Parse(dateLayout, req.CheckInDate)
+	if err != nil {
+		return input, errors.NewValidationError("check_in_date", "must be a date in YYYY-MM-DD format")
+	}
+	input.CheckInDate = checkIn
+
+	checkOut, err := time.Parse(dateLayout, req.CheckOutDate)
+	if err != nil {
+		return input, errors.NewValidationError("check_out_date", "must be a date in YYYY-MM-DD format")
+	}
+	input.CheckOutDate = checkOut
+
+	return input, nil
+}
+
+func (h *ReservationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	var req reservationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	input, err := req.toInput()
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	input.CreatedBy = user.ID
+
+	reservation, err := h.reservationService.Create(r.Context(), input)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+func (h *ReservationHandler) List(w http.ResponseWriter, r *http.Request) {
+	reservations, err := h.reservationService.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservations)
+}
+
+func (h *ReservationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("reservation"))
+		return
+	}
+
+	reservation, err := h.reservationService.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+func (h *ReservationHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, models.ReservationStatusConfirmed)
+}
+
+func (h *ReservationHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, models.ReservationStatusCancelled)
+}
+
+func (h *ReservationHandler) NoShow(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, models.ReservationStatusNoShow)
+}
+
+func (h *ReservationHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, models.ReservationStatusCheckedIn)
+}
+
+func (h *ReservationHandler) CheckOut(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, models.ReservationStatusCheckedOut)
+}
+
+func (h *ReservationHandler) transition(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("reservation"))
+		return
+	}
+
+	user, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	reservation, err := h.reservationService.Transition(r.Context(), id, status, user.ID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+func (h *ReservationHandler) History(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("reservation"))
+		return
+	}
+
+	changes, err := h.reservationService.History(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, changes)
+}
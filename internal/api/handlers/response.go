@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ruanv123/acme-hotel-api/internal/api/render"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+)
+
+var errUnauthenticated = errors.ErrUnauthenticated
+
+// invalidIDError reports a path parameter that isn't a valid ID of resource
+func invalidIDError(resource string) error {
+	return errors.WithCode(errors.ErrInvalidInput, "INVALID_ID", "invalid "+resource+" ID")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	render.JSON(w, status, v)
+}
+
+// writeServiceError translates errors returned by the service layer into
+// HTTP responses without leaking internal details to the client
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	render.Error(w, r, err)
+}
+
+// NotFound answers requests to unknown routes
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeServiceError(w, r, errors.WithCode(errors.ErrNotFound, errors.CodeNotFound, "route not found"))
+}
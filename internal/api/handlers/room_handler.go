@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+type RoomHandler struct {
+	roomService service.RoomService
+}
+
+func NewRoomHandler(roomService service.RoomService) *RoomHandler {
+	return &RoomHandler{
+		roomService: roomService,
+	}
+}
+
+type roomRequest struct {
+	Number    int          `json:"number" validate:"required"`
+	Type      string       `json:"type" validate:"required,max=50"`
+	Capacity  int          `json:"capacity" validate:"required"`
+	DailyRate money.Amount `json:"daily_rate" validate:"required"`
+	Currency  string       `json:"currency"`
+	Status    string       `json:"status"`
+}
+
+func (req roomRequest) toInput() service.RoomInput {
+	return service.RoomInput{
+		Number:    req.Number,
+		Type:      req.Type,
+		Capacity:  req.Capacity,
+		DailyRate: req.DailyRate,
+		Currency:  req.Currency,
+		Status:    req.Status,
+	}
+}
+
+func (h *RoomHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req roomRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	room, err := h.roomService.Create(r.Context(), req.toInput())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, room)
+}
+
+func (h *RoomHandler) List(w http.ResponseWriter, r *http.Request) {
+	rooms, err := h.roomService.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rooms)
+}
+
+func (h *RoomHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("room"))
+		return
+	}
+
+	room, err := h.roomService.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (h *RoomHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("room"))
+		return
+	}
+
+	var req roomRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	room, err := h.roomService.Update(r.Context(), id, req.toInput())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (h *RoomHandler) Retire(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("room"))
+		return
+	}
+
+	room, err := h.roomService.Retire(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (h *RoomHandler) MarkCleaned(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, r, invalidIDError("room"))
+		return
+	}
+
+	room, err := h.roomService.MarkCleaned(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, room)
+}
+
+type availabilityResponse struct {
+	Room       models.Room  `json:"room"`
+	Nights     int          `json:"nights"`
+	TotalPrice money.Amount `json:"total_price"`
+	Currency   string       `json:"currency"`
+}
+
+// Availability lists the rooms free for a stay, e.g.
+// GET /api/v1/availability?check_in_date=2024-12-12&check_out_date=2024-12-15&guests=3&type=suite
+func (h *RoomHandler) Availability(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	checkIn, err := time.Parse(dateLayout, params.Get("check_in_date"))
+	if err != nil {
+		writeServiceError(w, r, errors.NewValidationError("check_in_date", "must be a date in YYYY-MM-DD format"))
+		return
+	}
+
+	checkOut, err := time.Parse(dateLayout, params.Get("check_out_date"))
+	if err != nil {
+		writeServiceError(w, r, errors.NewValidationError("check_out_date", "must be a date in YYYY-MM-DD format"))
+		return
+	}
+
+	guests := 1
+	if value := params.Get("guests"); value != "" {
+		guests, err = strconv.Atoi(value)
+		if err != nil {
+			writeServiceError(w, r, errors.NewValidationError("guests", "must be a number"))
+			return
+		}
+	}
+
+	availability, err := h.roomService.Availability(r.Context(), service.AvailabilityQuery{
+		CheckInDate:  checkIn,
+		CheckOutDate: checkOut,
+		Guests:       guests,
+		Type:         params.Get("type"),
+	})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := make([]availabilityResponse, 0, len(availability))
+	for _, a := range availability {
+		resp = append(resp, availabilityResponse{
+			Room:       a.Room,
+			Nights:     a.Nights,
+			TotalPrice: a.TotalPrice,
+			Currency:   a.Room.Currency,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
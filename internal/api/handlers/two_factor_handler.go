@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+// TwoFactorHandler serves the two-factor settings of the signed in user
+// under /api/v1/me/2fa
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type twoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type twoFactorEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	user, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(r.Context(), user.ID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, twoFactorEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OtpauthURI: enrollment.URI,
+	})
+}
+
+func (h *TwoFactorHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	user, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	var req twoFactorCodeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	codes, err := h.twoFactorService.Activate(r.Context(), user.ID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	user, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	var req twoFactorDisableRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), user.ID, req.Password, req.Code); err != nil {
+		writeTwoFactorError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user, ok := service.UserFromContext(r.Context())
+	if !ok {
+		writeServiceError(w, r, errUnauthenticated)
+		return
+	}
+
+	var req twoFactorCodeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(r.Context(), user.ID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// writeTwoFactorError reports a wrong code as an invalid field; outside of
+// the login it doesn't mean the user isn't authenticated
+func writeTwoFactorError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		err = apperrors.NewValidationError("code", "is not a valid two-factor authentication code")
+	}
+	writeServiceError(w, r, err)
+}
@@ -0,0 +1,140 @@
+// Package render writes the JSON responses of the API, turning the errors
+// of the service layer into a consistent error envelope:
+//
+//	{"error": {"code": "VALIDATION_FAILED", "message": "...",
+//	           "fields": [{"field": "cpf", "message": "..."}],
+//	           "request_id": "..."}}
+package render
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey string
+
+const requestIDContextKey contextKey = "request_id"
+
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []fieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// errorKinds maps the sentinels of the errors package to a status and a
+// default code, checked in order
+var errorKinds = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{errors.ErrNotFound, http.StatusNotFound, errors.CodeNotFound},
+	{errors.ErrAlreadyExists, http.StatusConflict, errors.CodeAlreadyExists},
+	{errors.ErrConflict, http.StatusConflict, errors.CodeConflict},
+	{errors.ErrInvalidInput, http.StatusBadRequest, errors.CodeInvalidInput},
+	{errors.ErrInvalidCredentials, http.StatusUnauthorized, errors.CodeInvalidCredentials},
+	{errors.ErrUnauthenticated, http.StatusUnauthorized, errors.CodeUnauthenticated},
+	{errors.ErrInsufficientPermission, http.StatusForbidden, errors.CodeForbidden},
+	{errors.ErrTooManyRequests, http.StatusTooManyRequests, errors.CodeTooManyRequests},
+	{errors.ErrPayloadTooLarge, http.StatusRequestEntityTooLarge, errors.CodePayloadTooLarge},
+}
+
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Error writes err as an error envelope. Errors that don't match a known
+// kind are logged and answered with a generic 500 so internal details don't
+// leak to the client.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	requestID := RequestIDFromContext(r.Context())
+
+	var validationErrs errors.ValidationErrors
+	var validationErr *errors.ValidationError
+	switch {
+	case stderrors.As(err, &validationErrs):
+		fields := make([]fieldError, len(validationErrs))
+		for i, fieldErr := range validationErrs {
+			fields[i] = fieldError{Field: fieldErr.Field, Message: fieldErr.Message}
+		}
+		JSON(w, http.StatusUnprocessableEntity, errorEnvelope{Error: errorBody{
+			Code:      errors.CodeValidationFailed,
+			Message:   "the request has invalid fields",
+			Fields:    fields,
+			RequestID: requestID,
+		}})
+		return
+	case stderrors.As(err, &validationErr):
+		JSON(w, http.StatusUnprocessableEntity, errorEnvelope{Error: errorBody{
+			Code:      errors.CodeValidationFailed,
+			Message:   validationErr.Error(),
+			Fields:    []fieldError{{Field: validationErr.Field, Message: validationErr.Message}},
+			RequestID: requestID,
+		}})
+		return
+	}
+
+	for _, kind := range errorKinds {
+		if !stderrors.Is(err, kind.err) {
+			continue
+		}
+
+		JSON(w, kind.status, errorEnvelope{Error: errorBody{
+			Code:      codeOf(err, kind.code),
+			Message:   err.Error(),
+			RequestID: requestID,
+		}})
+		return
+	}
+
+	logger.LogEvent(logrus.ErrorLevel, "Request failed", logrus.Fields{
+		"error":      err.Error(),
+		"request_id": requestID,
+	})
+	JSON(w, http.StatusInternalServerError, errorEnvelope{Error: errorBody{
+		Code:      errors.CodeInternal,
+		Message:   "internal server error",
+		RequestID: requestID,
+	}})
+}
+
+// codeOf returns the code of the outermost *errors.Error in err's chain
+// that has a specific one, or fallback
+func codeOf(err error, fallback string) string {
+	for err != nil {
+		if coded, ok := err.(*errors.Error); ok && coded.Code != "" && coded.Code != errors.CodeInternal {
+			return coded.Code
+		}
+		err = stderrors.Unwrap(err)
+	}
+	return fallback
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the ID middleware.RequestID gave the
+// request, or "" outside of a request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
@@ -0,0 +1,91 @@
+// Package background runs the work the API does outside of a request, such
+// as sending e-mails, so it can be waited for when the server shuts down.
+package background
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group tracks background tasks. Tasks get a context that is cancelled
+// when Shutdown gives up waiting for them.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// stopping is closed when Shutdown starts, ending the periodic tasks
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+	}
+}
+
+// Go runs task in a new goroutine with a context that expires after
+// timeout. It must not be called once Shutdown has returned.
+func (g *Group) Go(timeout time.Duration, task func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		ctx, cancel := context.WithTimeout(g.ctx, timeout)
+		defer cancel()
+
+		task(ctx)
+	}()
+}
+
+// Every runs task every interval, each run with a context that expires
+// after timeout, until Shutdown is called. A run in progress is waited for
+// like any other task.
+func (g *Group) Every(interval, timeout time.Duration, task func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-g.stopping:
+				return
+			case <-ticker.C:
+			}
+
+			ctx, cancel := context.WithTimeout(g.ctx, timeout)
+			task(ctx)
+			cancel()
+		}
+	}()
+}
+
+// Shutdown waits for the running tasks to finish. If ctx expires first the
+// tasks are cancelled, and Shutdown still waits for them to return before
+// reporting ctx's error.
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.stopOnce.Do(func() { close(g.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		g.cancel()
+		return nil
+	case <-ctx.Done():
+		g.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
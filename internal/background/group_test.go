@@ -0,0 +1,40 @@
+package background
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEveryStopsOnShutdown(t *testing.T) {
+	group := NewGroup()
+
+	var runs atomic.Int32
+	ran := make(chan struct{}, 1)
+	group.Every(time.Millisecond, time.Second, func(ctx context.Context) {
+		runs.Add(1)
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+	})
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("the task never ran")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := group.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown returned %v", err)
+	}
+
+	stopped := runs.Load()
+	time.Sleep(10 * time.Millisecond)
+	if got := runs.Load(); got != stopped {
+		t.Errorf("the task ran %d more times after Shutdown", got-stopped)
+	}
+}
@@ -0,0 +1,350 @@
+// Package config loads the settings of the API. Every setting has a
+// default, can be set in the YAML file named by CONFIG_FILE and can be
+// overridden by an environment variable, which may come from a .env file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	// Env is "production", or "development" to relax the settings that
+	// must be explicit in production
+	Env      string         `yaml:"env" env:"APP_ENV"`
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Mail     MailConfig     `yaml:"mail"`
+	CORS     CORSConfig     `yaml:"cors"`
+}
+
+type ServerConfig struct {
+	Port         string        `yaml:"port" env:"PORT"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT"`
+	// ShutdownTimeout is how long in-flight requests and background tasks
+	// get to finish once a shutdown starts
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// ShutdownDelay is how long /readyz reports the shutdown before the
+	// server stops accepting connections
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+	// TrustedProxies lists the IPs or CIDR ranges of the reverse proxies
+	// whose X-Forwarded-For header is believed for the client address
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+type DatabaseConfig struct {
+	URL             string        `yaml:"url" env:"DATABASE_URL" secret:"true"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	MigrateOnBoot   bool          `yaml:"migrate_on_boot" env:"MIGRATE_ON_BOOT"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+	// RegistrationMode is "invite" to only let invited users register, or
+	// "open" to let anyone create an account
+	RegistrationMode  string `yaml:"registration_mode" env:"REGISTRATION_MODE"`
+	TwoFactorRequired bool   `yaml:"two_factor_required" env:"TWO_FACTOR_REQUIRED"`
+}
+
+type MailConfig struct {
+	// Driver is "smtp", or "log" to append the messages to LogPath
+	Driver           string `yaml:"driver" env:"MAIL_DRIVER"`
+	SMTPHost         string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort         string `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername     string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword     string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	From             string `yaml:"from" env:"MAIL_FROM"`
+	LogPath          string `yaml:"log_path" env:"MAIL_LOG_PATH"`
+	PasswordResetURL string `yaml:"password_reset_url" env:"PASSWORD_RESET_URL"`
+	InvitationURL    string `yaml:"invitation_url" env:"INVITATION_URL"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins lists origins such as https://app.example.com; an
+	// origin like https://*.example.com matches any of its subdomains and
+	// "*" on its own allows every origin. None are allowed by default.
+	AllowedOrigins []string `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods []string `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders []string `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	// AllowCredentials lets the listed origins send cookies and
+	// authorization headers. It never applies to "*".
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// Default returns the settings used for anything left unset
+func Default() Config {
+	return Config{
+		Env: "production",
+		Server: ServerConfig{
+			Port:            "5050",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:   15 * time.Minute,
+			RefreshTokenTTL:  7 * 24 * time.Hour,
+			RegistrationMode: "invite",
+		},
+		Mail: MailConfig{
+			Driver:           "log",
+			SMTPPort:         "587",
+			LogPath:          "mail.log",
+			PasswordResetURL: "http://localhost:3000/reset-password",
+			InvitationURL:    "http://localhost:3000/register",
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{
+				"Accept",
+				"Authorization",
+				"Content-Type",
+				"X-CSRF-Token",
+				"X-API-Key",
+				"X-Request-ID",
+			},
+			AllowCredentials: true,
+			MaxAge:           5 * time.Minute,
+		},
+	}
+}
+
+// Load reads .env into the environment if it exists, then builds the
+// configuration from the defaults, the YAML file named by CONFIG_FILE and
+// the environment, in increasing order of precedence, and validates it
+func Load() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadDatabase is Load for the tools that only talk to the database, such
+// as the migrate command: only the database settings are validated, so the
+// other sections must not be used
+func LoadDatabase() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	var p problems
+	cfg.Database.validate(&p)
+	if err := p.err(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("loading .env: %v", err)
+	}
+
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %v", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// loadEnv overrides the settings whose variable lookup finds
+func (c *Config) loadEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+	for _, s := range c.settings() {
+		if s.env == "" {
+			continue
+		}
+		raw, ok := lookup(s.env)
+		if !ok {
+			continue
+		}
+		if err := s.set(strings.TrimSpace(raw)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", s.env, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// problems collects the settings that failed validation
+type problems []string
+
+func (p *problems) check(ok bool, problem string) {
+	if !ok {
+		*p = append(*p, problem)
+	}
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(p, "\n  - "))
+}
+
+// Validate reports every setting that is missing or out of range
+func (c *Config) Validate() error {
+	var p problems
+	check := p.check
+
+	check(c.Env == "production" || c.Env == "development", "APP_ENV must be production or development")
+
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port < 65536, "PORT must be a port number")
+	check(c.Server.ReadTimeout > 0, "READ_TIMEOUT must be positive")
+	check(c.Server.WriteTimeout > 0, "WRITE_TIMEOUT must be positive")
+	check(c.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+	check(c.Server.ShutdownDelay >= 0, "SHUTDOWN_DELAY must not be negative")
+	for _, proxy := range c.Server.TrustedProxies {
+		check(isIPOrCIDR(proxy), "TRUSTED_PROXIES has an invalid IP or CIDR range "+proxy)
+	}
+
+	c.Database.validate(&p)
+
+	check(c.Auth.JWTSecret != "", "JWT_SECRET is required")
+	check(c.Auth.AccessTokenTTL > 0, "ACCESS_TOKEN_TTL must be positive")
+	check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "REFRESH_TOKEN_TTL must be longer than ACCESS_TOKEN_TTL")
+	check(c.Auth.RegistrationMode == "invite" || c.Auth.RegistrationMode == "open",
+		"REGISTRATION_MODE must be invite or open")
+
+	switch c.Mail.Driver {
+	case "smtp":
+		check(c.Mail.SMTPHost != "", "SMTP_HOST is required when MAIL_DRIVER=smtp")
+		check(c.Mail.From != "", "MAIL_FROM is required when MAIL_DRIVER=smtp")
+	case "log":
+		check(c.Mail.LogPath != "", "MAIL_LOG_PATH is required when MAIL_DRIVER=log")
+	default:
+		check(false, "MAIL_DRIVER must be smtp or log")
+	}
+	check(isAbsoluteURL(c.Mail.PasswordResetURL), "PASSWORD_RESET_URL must be an absolute URL")
+	check(isAbsoluteURL(c.Mail.InvitationURL), "INVITATION_URL must be an absolute URL")
+
+	check(len(c.CORS.AllowedOrigins) > 0 || c.Env == "development",
+		"CORS_ALLOWED_ORIGINS must list at least one origin outside development")
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			check(len(c.CORS.AllowedOrigins) == 1, "CORS_ALLOWED_ORIGINS can't mix * with other origins")
+			continue
+		}
+		check(isOrigin(origin), "CORS_ALLOWED_ORIGINS has an invalid origin "+origin)
+	}
+	check(len(c.CORS.AllowedMethods) > 0, "CORS_ALLOWED_METHODS must list at least one method")
+	check(c.CORS.MaxAge >= 0, "CORS_MAX_AGE must not be negative")
+
+	return p.err()
+}
+
+func (d *DatabaseConfig) validate(p *problems) {
+	p.check(d.URL != "", "DATABASE_URL is required")
+	p.check(d.MaxOpenConns > 0, "DB_MAX_OPEN_CONNS must be at least 1")
+	p.check(d.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	p.check(d.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+}
+
+// Redacted lists every setting by its dotted YAML path, with the secrets
+// that are set masked, for logging at startup
+func (c *Config) Redacted() map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, s := range c.settings() {
+		value := s.value.Interface()
+		if s.secret {
+			if s.value.IsZero() {
+				value = ""
+			} else {
+				value = "[REDACTED]"
+			}
+		}
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		values[s.path] = value
+	}
+
+	return values
+}
+
+// isOrigin reports whether raw is a scheme and host, optionally with a port,
+// where the host may start with "*." to stand for any subdomain
+func isOrigin(raw string) bool {
+	scheme, host, ok := strings.Cut(raw, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return false
+	}
+
+	host = strings.TrimPrefix(host, "*.")
+	if host == "" || strings.ContainsAny(host, "*/?#@") {
+		return false
+	}
+
+	u, err := url.Parse(scheme + "://" + host)
+	return err == nil && u.Hostname() != ""
+}
+
+func isIPOrCIDR(raw string) bool {
+	if strings.Contains(raw, "/") {
+		_, err := netip.ParsePrefix(raw)
+		return err == nil
+	}
+	_, err := netip.ParseAddr(raw)
+	return err == nil
+}
+
+func isAbsoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
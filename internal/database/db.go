@@ -6,18 +6,14 @@ import (
 	"os"
 	"time"
 
-	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-func InitDB() (*gorm.DB, error) {
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL environment variable is required")
-	}
-
+// InitDB opens the connection pool sized by cfg
+func InitDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	// Configure GORM logger
 	gormLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
@@ -30,25 +26,22 @@ func InitDB() (*gorm.DB, error) {
 	)
 
 	// Open connection
-	db, err := gorm.Open(postgres.Open(dbURL), &gorm.Config{
-		Logger: gormLogger,
+	db, err := gorm.Open(postgres.Open(cfg.URL), &gorm.Config{
+		Logger:         gormLogger,
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %v", err)
 	}
 
-	if err := autoMigrate(db); err != nil {
-		return nil, fmt.Errorf("error migrating database: %v", err)
-	}
-	//migrations.MigrateLandmarks(db)
-	return db, nil
-}
-
-func autoMigrate(db *gorm.DB) error {
-	/*err := db.Migrator().DropTable(&models.SubmissionLandmark{}, &models.SubmissionLandmarkDetail{}, &models.SubmissionLandmarkImage{})
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Failed to drop tables: ", err)
+		return nil, fmt.Errorf("error getting the underlying *sql.DB: %v", err)
 	}
-	return db.AutoMigrate(&models.SubmissionLandmark{}, &models.SubmissionLandmarkDetail{}, &models.SubmissionLandmarkImage{})*/
-	return db.AutoMigrate(&models.User{}, &models.Guest{})
+
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	return db, nil
 }
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ruanv123/acme-hotel-api/internal/database/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(content string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(content)}
+	}
+
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int
+		wantErr      string
+	}{
+		{
+			name: "sorted by version, not by file name",
+			files: fstest.MapFS{
+				"10_ten.up.sql":     file("up 10"),
+				"10_ten.down.sql":   file("down 10"),
+				"0002_two.up.sql":   file("up 2"),
+				"0002_two.down.sql": file("down 2"),
+				"1_one.up.sql":      file("up 1"),
+				"1_one.down.sql":    file("down 1"),
+			},
+			wantVersions: []int{1, 2, 10},
+		},
+		{
+			name:  "no migrations",
+			files: fstest.MapFS{"README.md": file("not sql")},
+		},
+		{
+			name:    "missing down file",
+			files:   fstest.MapFS{"0001_init.up.sql": file("up")},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name:    "missing up file",
+			files:   fstest.MapFS{"0001_init.down.sql": file("down")},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name:    "empty up file",
+			files:   fstest.MapFS{"0001_init.up.sql": file(""), "0001_init.down.sql": file("down")},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name:    "no direction",
+			files:   fstest.MapFS{"0001_init.sql": file("up")},
+			wantErr: "must end in .up.sql or .down.sql",
+		},
+		{
+			name:    "no name",
+			files:   fstest.MapFS{"0001.up.sql": file("up")},
+			wantErr: "must be named <version>_<name>",
+		},
+		{
+			name:    "version is not a number",
+			files:   fstest.MapFS{"first_init.up.sql": file("up")},
+			wantErr: "has an invalid version",
+		},
+		{
+			name:    "version zero",
+			files:   fstest.MapFS{"0000_init.up.sql": file("up")},
+			wantErr: "has an invalid version",
+		},
+		{
+			name: "one version with two names",
+			files: fstest.MapFS{
+				"0001_init.up.sql":    file("up"),
+				"0001_other.down.sql": file("down"),
+			},
+			wantErr: "has two names",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := loadMigrations(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadMigrations returned %v; want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadMigrations returned %v", err)
+			}
+
+			if len(loaded) != len(tt.wantVersions) {
+				t.Fatalf("loaded %d migrations; want %d", len(loaded), len(tt.wantVersions))
+			}
+			for i, migration := range loaded {
+				if migration.Version != tt.wantVersions[i] {
+					t.Errorf("migration %d has version %d; want %d", i, migration.Version, tt.wantVersions[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMigrationsReadsBothDirections(t *testing.T) {
+	loaded, err := loadMigrations(fstest.MapFS{
+		"0001_create_rooms.up.sql":   {Data: []byte("CREATE TABLE rooms ();")},
+		"0001_create_rooms.down.sql": {Data: []byte("DROP TABLE rooms;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Migration{Version: 1, Name: "create_rooms", Up: "CREATE TABLE rooms ();", Down: "DROP TABLE rooms;"}
+	if len(loaded) != 1 || loaded[0] != want {
+		t.Errorf("loaded %+v; want [%+v]", loaded, want)
+	}
+}
+
+// TestEmbeddedMigrations guards the shipped files: every version from 1 to
+// the latest must be there exactly once, so none is skipped or reordered
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := loadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("embedded migrations don't load: %v", err)
+	}
+	if len(loaded) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	for i, migration := range loaded {
+		if migration.Version != i+1 {
+			t.Fatalf("migration %04d_%s follows version %d", migration.Version, migration.Name, i)
+		}
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("migration %04d_%s has an empty direction", migration.Version, migration.Name)
+		}
+	}
+
+	migrator := &Migrator{migrations: loaded}
+	if got := migrator.LatestVersion(); got != len(loaded) {
+		t.Errorf("LatestVersion() = %d; want %d", got, len(loaded))
+	}
+}
+
+func TestLatestVersionWithoutMigrations(t *testing.T) {
+	if got := (&Migrator{}).LatestVersion(); got != 0 {
+		t.Errorf("LatestVersion() = %d; want 0", got)
+	}
+}
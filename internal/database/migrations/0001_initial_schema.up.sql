@@ -0,0 +1,84 @@
+-- schema previously created by gorm's AutoMigrate; IF NOT EXISTS lets this
+-- run as a no-op on databases that were set up that way
+
+CREATE TABLE IF NOT EXISTS users (
+    id            uuid PRIMARY KEY,
+    name          varchar(255) NOT NULL,
+    email         varchar(255) NOT NULL,
+    password_hash varchar(255) NOT NULL,
+    role          varchar(255) NOT NULL DEFAULT 'user',
+    status        boolean NOT NULL DEFAULT true,
+    created_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS guests (
+    id          uuid PRIMARY KEY,
+    name        varchar(255) NOT NULL,
+    cpf         varchar(14) NOT NULL,
+    data_nasc   timestamptz NOT NULL,
+    telefone    varchar(15) NOT NULL,
+    email       varchar(255) NOT NULL,
+    observacoes text NOT NULL,
+    created_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_guests_cpf ON guests (cpf);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_guests_email ON guests (email);
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id         uuid PRIMARY KEY,
+    number     bigint NOT NULL,
+    type       varchar(50) NOT NULL,
+    capacity   bigint NOT NULL,
+    daily_rate double precision NOT NULL,
+    status     text NOT NULL DEFAULT 'available',
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rooms_number ON rooms (number);
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id             uuid PRIMARY KEY,
+    guest_id       uuid NOT NULL,
+    room_id        uuid NOT NULL,
+    check_in_date  date NOT NULL,
+    check_out_date date NOT NULL,
+    total_amount   decimal(10,2) NOT NULL,
+    status         text NOT NULL DEFAULT 'pending',
+    created_at     timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_reservations_guest FOREIGN KEY (guest_id) REFERENCES guests (id)
+        ON UPDATE CASCADE ON DELETE RESTRICT,
+    CONSTRAINT fk_reservations_room FOREIGN KEY (room_id) REFERENCES rooms (id)
+        ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS idx_reservations_guest_id ON reservations (guest_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_room_id ON reservations (room_id);
+
+CREATE TABLE IF NOT EXISTS reservation_status_changes (
+    id             uuid PRIMARY KEY,
+    reservation_id uuid NOT NULL,
+    from_status    varchar(20) NOT NULL,
+    to_status      varchar(20) NOT NULL,
+    changed_by_id  uuid NOT NULL,
+    changed_at     timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_reservation_status_changes_reservation FOREIGN KEY (reservation_id) REFERENCES reservations (id)
+        ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_reservation_status_changes_reservation_id ON reservation_status_changes (reservation_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id             uuid PRIMARY KEY,
+    reservation_id uuid NOT NULL,
+    amount_paid    decimal(10,2) NOT NULL,
+    payment_date   date NOT NULL,
+    payment_method text NOT NULL,
+    payment_status text NOT NULL DEFAULT 'Pendente',
+    created_at     timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_payments_reservation FOREIGN KEY (reservation_id) REFERENCES reservations (id)
+        ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_payments_reservation_id ON payments (reservation_id);
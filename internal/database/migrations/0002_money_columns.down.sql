@@ -0,0 +1,7 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS currency;
+ALTER TABLE reservations DROP COLUMN IF EXISTS currency;
+ALTER TABLE rooms DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE payments ALTER COLUMN amount_paid TYPE decimal(10,2);
+ALTER TABLE reservations ALTER COLUMN total_amount TYPE decimal(10,2);
+ALTER TABLE rooms ALTER COLUMN daily_rate TYPE double precision;
@@ -0,0 +1,10 @@
+-- money is stored as numeric(12,2) and read into money.Amount as exact
+-- cents; rounding happens in the database so no value goes through a float
+
+ALTER TABLE rooms ALTER COLUMN daily_rate TYPE numeric(12,2) USING ROUND(daily_rate::numeric, 2);
+ALTER TABLE reservations ALTER COLUMN total_amount TYPE numeric(12,2) USING ROUND(total_amount::numeric, 2);
+ALTER TABLE payments ALTER COLUMN amount_paid TYPE numeric(12,2) USING ROUND(amount_paid::numeric, 2);
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS currency char(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS currency char(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS currency char(3) NOT NULL DEFAULT 'BRL';
@@ -0,0 +1,79 @@
+CREATE TABLE roles (
+    id          uuid PRIMARY KEY,
+    name        varchar(50) NOT NULL,
+    description varchar(255) NOT NULL DEFAULT '',
+    created_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_roles_name ON roles (name);
+
+CREATE TABLE permissions (
+    id          uuid PRIMARY KEY,
+    code        varchar(100) NOT NULL,
+    description varchar(255) NOT NULL DEFAULT ''
+);
+CREATE UNIQUE INDEX idx_permissions_code ON permissions (code);
+
+CREATE TABLE role_permissions (
+    role_id       uuid NOT NULL,
+    permission_id uuid NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id) REFERENCES roles (id)
+        ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (id)
+        ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+INSERT INTO permissions (id, code, description) VALUES
+    (gen_random_uuid(), 'guests:read', 'View guests'),
+    (gen_random_uuid(), 'guests:write', 'Register, edit and delete guests'),
+    (gen_random_uuid(), 'rooms:read', 'Search room availability'),
+    (gen_random_uuid(), 'rooms:manage', 'Create, edit and retire rooms'),
+    (gen_random_uuid(), 'housekeeping:write', 'Mark rooms as cleaned'),
+    (gen_random_uuid(), 'reservations:read', 'View reservations'),
+    (gen_random_uuid(), 'reservations:write', 'Book, confirm, cancel, check in and check out reservations'),
+    (gen_random_uuid(), 'payments:read', 'View payments and balances'),
+    (gen_random_uuid(), 'payments:write', 'Record payments'),
+    (gen_random_uuid(), 'payments:refund', 'Refund payments'),
+    (gen_random_uuid(), 'users:manage', 'Manage staff accounts and roles');
+
+INSERT INTO roles (id, name, description) VALUES
+    (gen_random_uuid(), 'admin', 'Full access'),
+    (gen_random_uuid(), 'manager', 'Runs the hotel operation'),
+    (gen_random_uuid(), 'receptionist', 'Front desk'),
+    (gen_random_uuid(), 'housekeeping', 'Room cleaning staff'),
+    (gen_random_uuid(), 'accountant', 'Payments and financial reports'),
+    (gen_random_uuid(), 'user', 'Read-only access');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON (r.name, p.code) IN (
+    ('manager', 'guests:read'),
+    ('manager', 'guests:write'),
+    ('manager', 'rooms:read'),
+    ('manager', 'rooms:manage'),
+    ('manager', 'housekeeping:write'),
+    ('manager', 'reservations:read'),
+    ('manager', 'reservations:write'),
+    ('manager', 'payments:read'),
+    ('manager', 'payments:write'),
+    ('manager', 'payments:refund'),
+    ('receptionist', 'guests:read'),
+    ('receptionist', 'guests:write'),
+    ('receptionist', 'rooms:read'),
+    ('receptionist', 'reservations:read'),
+    ('receptionist', 'reservations:write'),
+    ('receptionist', 'payments:read'),
+    ('receptionist', 'payments:write'),
+    ('housekeeping', 'rooms:read'),
+    ('housekeeping', 'housekeeping:write'),
+    ('accountant', 'guests:read'),
+    ('accountant', 'reservations:read'),
+    ('accountant', 'payments:read'),
+    ('accountant', 'payments:write'),
+    ('accountant', 'payments:refund'),
+    ('user', 'guests:read'),
+    ('user', 'rooms:read'),
+    ('user', 'reservations:read')
+) OR r.name = 'admin';
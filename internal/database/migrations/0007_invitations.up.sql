@@ -0,0 +1,15 @@
+CREATE TABLE invitations (
+    id            uuid PRIMARY KEY,
+    email         varchar(255) NOT NULL,
+    role          varchar(50) NOT NULL,
+    token_hash    varchar(64) NOT NULL,
+    invited_by_id uuid,
+    expires_at    timestamptz NOT NULL,
+    accepted_at   timestamptz,
+    revoked_at    timestamptz,
+    created_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_invitations_invited_by FOREIGN KEY (invited_by_id) REFERENCES users (id)
+        ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX idx_invitations_token_hash ON invitations (token_hash);
+CREATE INDEX idx_invitations_email ON invitations (email);
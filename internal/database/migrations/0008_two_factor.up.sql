@@ -0,0 +1,15 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret    varchar(64) NOT NULL DEFAULT '',
+    ADD COLUMN totp_enabled   boolean NOT NULL DEFAULT false,
+    ADD COLUMN totp_last_step bigint;
+
+CREATE TABLE recovery_codes (
+    id         uuid PRIMARY KEY,
+    user_id    uuid NOT NULL,
+    code_hash  varchar(64) NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_recovery_codes_user FOREIGN KEY (user_id) REFERENCES users (id)
+        ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_recovery_codes_user_code ON recovery_codes (user_id, code_hash);
@@ -0,0 +1,25 @@
+CREATE TABLE api_keys (
+    id            uuid PRIMARY KEY,
+    name          varchar(100) NOT NULL,
+    prefix        varchar(16) NOT NULL,
+    key_hash      varchar(64) NOT NULL,
+    created_by_id uuid NOT NULL,
+    expires_at    timestamptz,
+    last_used_at  timestamptz,
+    revoked_at    timestamptz,
+    created_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_api_keys_created_by FOREIGN KEY (created_by_id) REFERENCES users (id)
+        ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX idx_api_keys_created_by_id ON api_keys (created_by_id);
+
+CREATE TABLE api_key_permissions (
+    api_key_id    uuid NOT NULL,
+    permission_id uuid NOT NULL,
+    PRIMARY KEY (api_key_id, permission_id),
+    CONSTRAINT fk_api_key_permissions_api_key FOREIGN KEY (api_key_id) REFERENCES api_keys (id)
+        ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_api_key_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (id)
+        ON UPDATE CASCADE ON DELETE CASCADE
+);
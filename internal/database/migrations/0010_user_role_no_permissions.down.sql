@@ -0,0 +1,8 @@
+UPDATE roles SET description = 'Read-only access' WHERE name = 'user';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON p.code IN ('guests:read', 'rooms:read', 'reservations:read')
+WHERE r.name = 'user'
+ON CONFLICT DO NOTHING;
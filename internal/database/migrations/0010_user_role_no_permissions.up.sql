@@ -0,0 +1,6 @@
+-- self-registered accounts get the user role, so it grants nothing until an
+-- admin assigns a staff role
+DELETE FROM role_permissions
+WHERE role_id IN (SELECT id FROM roles WHERE name = 'user');
+
+UPDATE roles SET description = 'No access until assigned a staff role' WHERE name = 'user';
@@ -0,0 +1,6 @@
+CREATE TABLE login_failures (
+    email        varchar(255) PRIMARY KEY,
+    attempts     integer NOT NULL DEFAULT 0,
+    locked_until timestamptz,
+    updated_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
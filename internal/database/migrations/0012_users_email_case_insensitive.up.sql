@@ -0,0 +1,24 @@
+-- accounts created before e-mails were normalized may differ only in case;
+-- merging them needs a human, so refuse to migrate until they are resolved
+DO $$
+DECLARE
+    duplicates text;
+BEGIN
+    SELECT string_agg(email, ', ') INTO duplicates
+    FROM (
+        SELECT lower(trim(email)) AS email
+        FROM users
+        GROUP BY lower(trim(email))
+        HAVING count(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users with e-mails differing only in case or spacing: %', duplicates
+            USING HINT = 'rename or delete the extra accounts, then migrate again';
+    END IF;
+END $$;
+
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX idx_users_email ON users (lower(email));
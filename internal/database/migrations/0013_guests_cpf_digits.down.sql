@@ -0,0 +1 @@
+-- the punctuation removed by the up migration can't be restored
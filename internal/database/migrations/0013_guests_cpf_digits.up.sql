@@ -0,0 +1,25 @@
+-- guests created before CPFs were normalized may store the formatted form,
+-- so the same CPF can belong to two guests. Merging them means moving their
+-- reservations, which needs a human, so refuse to migrate until they are
+-- resolved. The error names the guest IDs rather than the CPFs.
+DO $$
+DECLARE
+    duplicates text;
+BEGIN
+    SELECT string_agg(ids, '; ') INTO duplicates
+    FROM (
+        SELECT string_agg(id::text, ', ' ORDER BY created_at) AS ids
+        FROM guests
+        GROUP BY regexp_replace(cpf, '[.\- ]', '', 'g')
+        HAVING count(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'guests sharing a CPF: %', duplicates
+            USING HINT = 'merge or delete the extra guests, then migrate again';
+    END IF;
+END $$;
+
+UPDATE guests
+SET cpf = regexp_replace(cpf, '[.\- ]', '', 'g')
+WHERE cpf ~ '[.\- ]';
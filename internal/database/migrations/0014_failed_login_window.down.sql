@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_login_failures_last_failed_at;
+
+ALTER TABLE login_failures
+    DROP COLUMN IF EXISTS last_failed_at;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS last_failed_login_at;
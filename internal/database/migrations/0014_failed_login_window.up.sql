@@ -0,0 +1,13 @@
+-- failed logins further apart than the throttle window start the count
+-- over, so the time of the last one is kept
+ALTER TABLE users
+    ADD COLUMN last_failed_login_at timestamptz;
+
+ALTER TABLE login_failures
+    ADD COLUMN last_failed_at timestamptz;
+UPDATE login_failures SET last_failed_at = updated_at;
+ALTER TABLE login_failures
+    ALTER COLUMN last_failed_at SET NOT NULL,
+    ALTER COLUMN last_failed_at SET DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX idx_login_failures_last_failed_at ON login_failures (last_failed_at);
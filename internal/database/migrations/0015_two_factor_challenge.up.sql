@@ -0,0 +1,4 @@
+-- the challenge a password login hands out is only good for one two-factor
+-- login, so the pending one is kept until it is used
+ALTER TABLE users
+    ADD COLUMN two_factor_challenge_id uuid;
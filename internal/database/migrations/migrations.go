@@ -0,0 +1,11 @@
+// Package migrations holds the versioned SQL migrations of the database.
+//
+// Each migration is a pair of files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, where version is a zero padded number that
+// defines the order in which they are applied.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -5,14 +5,33 @@ import "errors"
 var (
 	ErrNotFound                 = errors.New("resource not found")
 	ErrAlreadyExists            = errors.New("resource already exists")
+	ErrConflict                 = errors.New("resource conflict")
 	ErrInvalidInput             = errors.New("invalid input")
+	ErrUnauthenticated          = errors.New("authentication required")
 	ErrInsufficientPermission   = errors.New("insufficient permission")
+	ErrTooManyRequests          = errors.New("too many requests")
+	ErrPayloadTooLarge          = errors.New("payload too large")
 	ErrDatabaseError            = errors.New("database error")
 	ErrCacheError               = errors.New("cache error")
 	ErrInvalidCredentials       = errors.New("invalid credentials")
 	ErrInsufficientSubscription = errors.New("insufficient subscription")
 )
 
+// machine readable codes sent to clients in error responses
+const (
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeNotFound           = "NOT_FOUND"
+	CodeAlreadyExists      = "ALREADY_EXISTS"
+	CodeConflict           = "CONFLICT"
+	CodeInvalidInput       = "INVALID_INPUT"
+	CodeValidationFailed   = "VALIDATION_FAILED"
+	CodeUnauthenticated    = "UNAUTHENTICATED"
+	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+	CodeForbidden          = "FORBIDDEN"
+	CodeTooManyRequests    = "TOO_MANY_REQUESTS"
+	CodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+)
+
 type Error struct {
 	Err     error
 	Message string
@@ -23,10 +42,27 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// Unwrap lets errors.Is and errors.As see the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 func Wrap(err error, message string) *Error {
 	return &Error{
 		Err:     err,
 		Message: message,
-		Code:    "INTERNAL_ERROR",
+		Code:    CodeInternal,
+	}
+}
+
+// WithCode defines an error of the given kind, one of the sentinels above,
+// with its own code and message, e.g.
+//
+//	ErrInvalidToken = errors.WithCode(errors.ErrUnauthenticated, "INVALID_TOKEN", "invalid token")
+func WithCode(kind error, code, message string) *Error {
+	return &Error{
+		Err:     kind,
+		Message: message,
+		Code:    code,
 	}
 }
@@ -0,0 +1,41 @@
+package errors
+
+import "strings"
+
+// ValidationError describes an invalid value for a single input field.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{
+		Field:   field,
+		Message: message,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Is lets errors.Is(err, ErrInvalidInput) match any validation error.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// ValidationErrors reports every invalid field of an input at once.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is lets errors.Is(err, ErrInvalidInput) match any validation error.
+func (e ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidInput
+}
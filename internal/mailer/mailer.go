@@ -0,0 +1,15 @@
+// Package mailer sends the transactional e-mails of the API.
+package mailer
+
+import "context"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers a plain text e-mail message
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
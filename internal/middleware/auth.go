@@ -1,35 +1,84 @@
 package middleware
 
 import (
-	"fmt"
+	stderrors "errors"
 	"net/http"
 	"strings"
 
+	"github.com/ruanv123/acme-hotel-api/internal/api/render"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
 	"github.com/ruanv123/acme-hotel-api/internal/service"
 )
 
-func AuthMiddleware(authService service.AuthService) func(http.Handler) http.Handler {
+// AuthMiddleware accepts either a bearer access token or an API key in the
+// X-API-Key header. Requests with an API key carry the key's creator as the
+// user and the key itself in the context. A credential that can't be
+// checked, e.g. while the database is down, is answered with a logged 500
+// rather than passed off as an invalid one.
+func AuthMiddleware(authService service.AuthService, apiKeyService service.APIKeyService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+				key, user, err := apiKeyService.Authenticate(r.Context(), apiKey)
+				if err != nil {
+					if !isRejected(err) {
+						render.Error(w, r, err)
+						return
+					}
+					render.Error(w, r, errors.ErrUnauthenticated)
+					return
+				}
+
+				ctx := service.WithUserContext(r.Context(), user)
+				ctx = service.WithAPIKeyContext(ctx, key)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			tokenString := extractTokenFromHeader(r)
 			if tokenString == "" {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				render.Error(w, r, errors.ErrUnauthenticated)
 				return
 			}
 
-			user, err := authService.VerifyToken(tokenString)
+			user, sessionID, err := authService.VerifySession(tokenString)
 			if err != nil {
-				fmt.Print(err)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				if !isRejected(err) {
+					render.Error(w, r, err)
+					return
+				}
+				render.Error(w, r, errors.ErrUnauthenticated)
 				return
 			}
 
 			ctx := service.WithUserContext(r.Context(), user)
+			ctx = service.WithSessionContext(ctx, sessionID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// isRejected reports whether err means the token or API key, or its user, is
+// not valid, as opposed to a failure to check it
+func isRejected(err error) bool {
+	return stderrors.Is(err, errors.ErrUnauthenticated) ||
+		stderrors.Is(err, errors.ErrInsufficientPermission) ||
+		stderrors.Is(err, errors.ErrNotFound)
+}
+
+// SessionOnly refuses requests authenticated with an API key, for the
+// routes acting on the user's own account
+func SessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := service.APIKeyFromContext(r.Context()); ok {
+			render.Error(w, r, errors.ErrInsufficientPermission)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func extractTokenFromHeader(r *http.Request) string {
 	bearerToken := r.Header.Get("Authorization")
 	if len(strings.Split(bearerToken, " ")) == 2 {
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	dbDown := errors.Wrap(stderrors.New("connection refused"), "failed to get API key")
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		err        error
+		wantStatus int
+	}{
+		{name: "no credentials", wantStatus: http.StatusUnauthorized},
+		{name: "valid API key", header: "X-API-Key", value: "key", wantStatus: http.StatusOK},
+		{name: "unknown API key", header: "X-API-Key", value: "key", err: service.ErrInvalidAPIKey, wantStatus: http.StatusUnauthorized},
+		{name: "API key of a disabled account", header: "X-API-Key", value: "key", err: service.ErrAccountDisabled, wantStatus: http.StatusUnauthorized},
+		{name: "API key that can't be checked", header: "X-API-Key", value: "key", err: dbDown, wantStatus: http.StatusInternalServerError},
+		{name: "valid token", header: "Authorization", value: "Bearer token", wantStatus: http.StatusOK},
+		{name: "invalid token", header: "Authorization", value: "Bearer token", err: service.ErrInvalidToken, wantStatus: http.StatusUnauthorized},
+		{name: "token of a deleted account", header: "Authorization", value: "Bearer token", err: errors.ErrNotFound, wantStatus: http.StatusUnauthorized},
+		{name: "token that can't be checked", header: "Authorization", value: "Bearer token", err: dbDown, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials := fakeCredentials{err: tt.err}
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := service.UserFromContext(r.Context()); !ok {
+					t.Error("the request reached the handler without a user")
+				}
+			})
+			handler := AuthMiddleware(credentials, credentials)(next)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d; want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+// fakeCredentials accepts every token and API key, or fails checking them
+// all with err
+type fakeCredentials struct {
+	service.AuthService
+	service.APIKeyService
+
+	err error
+}
+
+func (f fakeCredentials) Authenticate(ctx context.Context, plain string) (*models.APIKey, *models.User, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	return &models.APIKey{ID: uuid.New()}, &models.User{ID: uuid.New(), Status: true}, nil
+}
+
+func (f fakeCredentials) VerifySession(token string) (*models.User, uuid.UUID, error) {
+	if f.err != nil {
+		return nil, uuid.Nil, f.err
+	}
+	return &models.User{ID: uuid.New(), Status: true}, uuid.New(), nil
+}
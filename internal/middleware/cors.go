@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rs/cors"
+	"github.com/ruanv123/acme-hotel-api/internal/config"
+)
+
+// exposedHeaders are the response headers browsers may show to scripts
+var exposedHeaders = []string{
+	"Link",
+	"Retry-After",
+	requestIDHeader,
+}
+
+// CORS applies the cross-origin policy of cfg. Unless every origin is
+// allowed with "*", the listed origins are echoed back and may send
+// credentials when cfg allows it.
+func CORS(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	options := cors.Options{
+		AllowedMethods: cfg.AllowedMethods,
+		AllowedHeaders: cfg.AllowedHeaders,
+		ExposedHeaders: exposedHeaders,
+		MaxAge:         int(cfg.MaxAge.Seconds()),
+	}
+
+	if len(cfg.AllowedOrigins) == 1 && cfg.AllowedOrigins[0] == "*" {
+		// browsers refuse credentials with a wildcard origin anyway
+		options.AllowedOrigins = cfg.AllowedOrigins
+	} else {
+		matchers := make([]originMatcher, 0, len(cfg.AllowedOrigins))
+		for _, origin := range cfg.AllowedOrigins {
+			matchers = append(matchers, newOriginMatcher(origin))
+		}
+
+		options.AllowOriginFunc = func(origin string) bool {
+			origin = strings.ToLower(origin)
+			for _, match := range matchers {
+				if match(origin) {
+					return true
+				}
+			}
+			return false
+		}
+		options.AllowCredentials = cfg.AllowCredentials
+	}
+
+	return cors.New(options).Handler
+}
+
+type originMatcher func(origin string) bool
+
+// newOriginMatcher matches origin exactly, or, for an origin such as
+// https://*.example.com, any subdomain of example.com on that scheme and port
+func newOriginMatcher(pattern string) originMatcher {
+	pattern = strings.ToLower(pattern)
+
+	prefix, suffix, wildcard := strings.Cut(pattern, "*.")
+	if !wildcard {
+		return func(origin string) bool {
+			return origin == pattern
+		}
+	}
+
+	suffix = "." + suffix
+	return func(origin string) bool {
+		if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			return false
+		}
+
+		subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+		return isSubdomain(subdomain)
+	}
+}
+
+// isSubdomain reports whether s is made of one or more DNS labels, so a
+// wildcard can't swallow a port, a userinfo or another host
+func isSubdomain(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
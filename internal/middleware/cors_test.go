@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ruanv123/acme-hotel-api/internal/config"
+)
+
+func TestOriginMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com", "http://app.example.com", false},
+		{"https://app.example.com", "https://app.example.com:8443", false},
+		{"https://app.example.com", "https://example.com", false},
+		{"HTTPS://App.Example.com", "https://app.example.com", true},
+
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://my-app.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "http://app.example.com", false},
+		{"https://*.example.com", "https://app.example.com:8443", false},
+		{"https://*.example.com", "https://evil.com/.example.com", false},
+		{"https://*.example.com", "https://evil.com:1@x.example.com", false},
+		{"https://*.example.com", "https://appexample.com", false},
+		{"https://*.example.com", "https://.example.com", false},
+		{"https://*.example.com", "https://a..example.com", false},
+		{"https://*.example.com", "https://-app.example.com", false},
+		{"https://*.example.com", "https://app-.example.com", false},
+		{"https://*.example.com", "https://app.example.com.evil.com", false},
+
+		{"http://*.localhost:3000", "http://app.localhost:3000", true},
+		{"http://*.localhost:3000", "http://app.localhost:4000", false},
+		{"http://*.localhost:3000", "http://app.localhost", false},
+	}
+
+	for _, tt := range tests {
+		if got := newOriginMatcher(tt.pattern)(tt.origin); got != tt.want {
+			t.Errorf("pattern %q matching %q = %v; want %v", tt.pattern, tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	base := config.CORSConfig{
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           5 * time.Minute,
+	}
+	withOrigins := func(origins ...string) config.CORSConfig {
+		cfg := base
+		cfg.AllowedOrigins = origins
+		return cfg
+	}
+
+	tests := []struct {
+		name            string
+		cfg             config.CORSConfig
+		origin          string
+		wantOrigin      string
+		wantCredentials bool
+	}{
+		{"listed origin", withOrigins("https://app.example.com"), "https://app.example.com", "https://app.example.com", true},
+		{"wildcard subdomain", withOrigins("https://*.example.com"), "https://admin.example.com", "https://admin.example.com", true},
+		{"case-insensitive origin", withOrigins("https://app.example.com"), "https://APP.example.com", "https://APP.example.com", true},
+		{"unlisted origin", withOrigins("https://app.example.com"), "https://evil.com", "", false},
+		{"no origins configured", withOrigins(), "https://app.example.com", "", false},
+		{"any origin never gets credentials", withOrigins("*"), "https://evil.com", "*", false},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/rooms", nil)
+			r.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+
+			CORS(tt.cfg)(next).ServeHTTP(w, r)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q; want %q", got, tt.wantOrigin)
+			}
+			gotCredentials := w.Header().Get("Access-Control-Allow-Credentials") == "true"
+			if gotCredentials != tt.wantCredentials {
+				t.Errorf("credentials allowed = %v; want %v", gotCredentials, tt.wantCredentials)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	cfg := config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           5 * time.Minute,
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a preflight request reached the handler")
+	})
+
+	r := httptest.NewRequest(http.MethodOptions, "/api/v1/rooms", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", "POST")
+	r.Header.Set("Access-Control-Request-Headers", "authorization")
+	w := httptest.NewRecorder()
+
+	CORS(cfg)(next).ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "POST" {
+		t.Errorf("Access-Control-Allow-Methods = %q; want POST", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "300" {
+		t.Errorf("Access-Control-Max-Age = %q; want 300", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/ruanv123/acme-hotel-api/internal/api/render"
 	"github.com/ruanv123/acme-hotel-api/internal/logger"
 	"github.com/sirupsen/logrus"
 )
@@ -25,6 +26,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			"status_code":   rw.statusCode,
 			"response_time": time.Since(start).Milliseconds(),
 			"ip":            r.RemoteAddr,
+			"request_id":    render.RequestIDFromContext(r.Context()),
 		})
 	})
 }
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ruanv123/acme-hotel-api/internal/api/render"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+// RequirePermission only lets through users whose role grants permission,
+// and for API keys only if the key is also scoped to it. It must run after
+// AuthMiddleware, which puts the user in the context.
+func RequirePermission(roleService service.RoleService, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := service.UserFromContext(r.Context())
+			if !ok {
+				render.Error(w, r, errors.ErrUnauthenticated)
+				return
+			}
+
+			if key, ok := service.APIKeyFromContext(r.Context()); ok && !key.HasScope(permission) {
+				render.Error(w, r, errors.ErrInsufficientPermission)
+				return
+			}
+
+			allowed, err := roleService.HasPermission(r.Context(), user, permission)
+			if err != nil {
+				render.Error(w, r, fmt.Errorf("permission check for %s failed: %v", permission, err))
+				return
+			}
+			if !allowed {
+				render.Error(w, r, errors.ErrInsufficientPermission)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
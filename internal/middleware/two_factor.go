@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ruanv123/acme-hotel-api/internal/api/render"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/service"
+)
+
+var errTwoFactorRequired = errors.WithCode(errors.ErrInsufficientPermission, "TWO_FACTOR_REQUIRED", "two-factor authentication must be enabled")
+
+// RequireTwoFactor only lets through users who turned on two-factor
+// authentication, and API keys. It must run after AuthMiddleware, which puts
+// the user in the context.
+func RequireTwoFactor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := service.UserFromContext(r.Context())
+		if !ok {
+			render.Error(w, r, errors.ErrUnauthenticated)
+			return
+		}
+
+		if _, isAPIKey := service.APIKeyFromContext(r.Context()); !isAPIKey && !user.TOTPEnabled {
+			render.Error(w, r, errTwoFactorRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
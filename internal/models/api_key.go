@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey authenticates an integration through the X-API-Key header. It acts
+// on behalf of the admin who created it, limited to its scopes. Only the
+// key hash is stored; Prefix identifies the key in listings.
+type APIKey struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string       `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix      string       `gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash     string       `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	CreatedByID uuid.UUID    `gorm:"type:uuid;not null;index" json:"created_by_id"`
+	Scopes      []Permission `gorm:"many2many:api_key_permissions;joinForeignKey:APIKeyID;joinReferences:PermissionID" json:"scopes"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time   `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time   `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	CreatedBy User `gorm:"foreignKey:CreatedByID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Active reports whether the key is neither revoked nor expired
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// HasScope reports whether the key was granted the permission code
+func (k *APIKey) HasScope(code string) bool {
+	for _, scope := range k.Scopes {
+		if scope.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	if k.CreatedAt.IsZero() {
+		k.CreatedAt = time.Now()
+	}
+
+	return nil
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
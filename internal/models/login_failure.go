@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// LoginFailure counts the failed logins for an e-mail that belongs to no
+// account, so that it gets locked like a real account would and the lockout
+// can't tell which e-mails are registered
+type LoginFailure struct {
+	Email        string     `gorm:"type:varchar(255);primaryKey" json:"email"`
+	Attempts     int        `gorm:"not null;default:0" json:"attempts"`
+	LastFailedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP;index" json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+	UpdatedAt    time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (LoginFailure) TableName() string {
+	return "login_failures"
+}
@@ -4,18 +4,59 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"gorm.io/gorm"
+)
+
+const (
+	PaymentStatusPending = "Pendente"
+	PaymentStatusPaid    = "Pago"
+)
+
+const (
+	PaymentMethodCash         = "cash"
+	PaymentMethodCreditCard   = "credit_card"
+	PaymentMethodDebitCard    = "debit_card"
+	PaymentMethodPix          = "pix"
+	PaymentMethodBankTransfer = "bank_transfer"
 )
 
 type Payment struct {
-	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	ReservationID uuid.UUID `gorm:"not null" json:"reservation_id"`
-	AmountPaid    float64   `gorm:"type:decimal(10,2);not null" json:"amount_paid"`
-	PaymentDate   time.Time `gorm:"type:date;not null" json:"payment_date"`
-	PaymentMethod string    `gorm:"not null" json:"payment_method"`
-	PaymentStatus string    `gorm:"default:'Pendente';not null" json:"payment_status"`
+	ID            uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	ReservationID uuid.UUID    `gorm:"type:uuid;not null;index" json:"reservation_id"`
+	AmountPaid    money.Amount `gorm:"type:numeric(12,2);not null" json:"amount_paid"`
+	Currency      string       `gorm:"type:char(3);not null;default:'BRL'" json:"currency"`
+	PaymentDate   time.Time    `gorm:"type:date;not null" json:"payment_date"`
+	PaymentMethod string       `gorm:"not null" json:"payment_method"`
+	PaymentStatus string       `gorm:"default:'Pendente';not null" json:"payment_status"`
 
-	Reservation Reservation `gorm:"foreignKey:ReservationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"` // FK
+	Reservation Reservation `gorm:"foreignKey:ReservationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"` // FK
 
 	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
+
+func (p *Payment) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+
+	now := time.Now()
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = now
+	}
+	if p.UpdatedAt.IsZero() {
+		p.UpdatedAt = now
+	}
+
+	return nil
+}
+
+func (p *Payment) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+func (Payment) TableName() string {
+	return "payments"
+}
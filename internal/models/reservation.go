@@ -4,20 +4,92 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"gorm.io/gorm"
 )
 
+// reservation lifecycle:
+// pending -> confirmed -> checked_in -> checked_out
+// pending and confirmed bookings may be cancelled, confirmed ones may be
+// marked as no_show
+const (
+	ReservationStatusPending    = "pending"
+	ReservationStatusConfirmed  = "confirmed"
+	ReservationStatusCheckedIn  = "checked_in"
+	ReservationStatusCheckedOut = "checked_out"
+	ReservationStatusCancelled  = "cancelled"
+	ReservationStatusNoShow     = "no_show"
+)
+
+// ActiveReservationStatuses lists the statuses that hold a room for their
+// date range and therefore block overlapping bookings
+var ActiveReservationStatuses = []string{
+	ReservationStatusPending,
+	ReservationStatusConfirmed,
+	ReservationStatusCheckedIn,
+}
+
+// reservationTransitions maps each status to the statuses it may move to
+var reservationTransitions = map[string][]string{
+	ReservationStatusPending:   {ReservationStatusConfirmed, ReservationStatusCancelled},
+	ReservationStatusConfirmed: {ReservationStatusCheckedIn, ReservationStatusCancelled, ReservationStatusNoShow},
+	ReservationStatusCheckedIn: {ReservationStatusCheckedOut},
+}
+
+// CanTransitionTo reports whether the reservation may move to status
+func (r *Reservation) CanTransitionTo(status string) bool {
+	for _, allowed := range reservationTransitions[r.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsPayments reports whether payments may still be recorded against
+// the reservation
+func (r *Reservation) AcceptsPayments() bool {
+	return r.Status != ReservationStatusCancelled
+}
+
 type Reservation struct {
-	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	GuestID      uuid.UUID `gorm:"type:uuid;not null" json:"guest_id"`
-	RoomID       uuid.UUID `gorm:"type:uuid;not null" json:"room_id"`
-	CheckInDate  time.Time `gorm:"type:date;not null" json:"check_in_date"`
-	CheckOutDate time.Time `gorm:"type:date;not null" json:"check_out_date"`
-	TotalAmount  float64   `gorm:"type:decimal(10,2);not null" json:"total_amount"`
-	Status       string    `gorm:"not null;default:'available'" json:"status"`
+	ID           uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	GuestID      uuid.UUID    `gorm:"type:uuid;not null;index" json:"guest_id"`
+	RoomID       uuid.UUID    `gorm:"type:uuid;not null;index" json:"room_id"`
+	CheckInDate  time.Time    `gorm:"type:date;not null" json:"check_in_date"`
+	CheckOutDate time.Time    `gorm:"type:date;not null" json:"check_out_date"`
+	TotalAmount  money.Amount `gorm:"type:numeric(12,2);not null" json:"total_amount"`
+	Currency     string       `gorm:"type:char(3);not null;default:'BRL'" json:"currency"`
+	Status       string       `gorm:"not null;default:'pending'" json:"status"`
 
-	Guest Guest `gorm:"foreignKey:GuestID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // FK para hóspede
-	Room  Room  `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`  // FK para quarto
+	Guest Guest `gorm:"foreignKey:GuestID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"guest,omitempty"` // FK para hóspede
+	Room  Room  `gorm:"foreignKey:RoomID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"room,omitempty"`   // FK para quarto
 
 	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
+
+func (r *Reservation) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+
+	now := time.Now()
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = now
+	}
+	if r.UpdatedAt.IsZero() {
+		r.UpdatedAt = now
+	}
+
+	return nil
+}
+
+func (r *Reservation) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+func (Reservation) TableName() string {
+	return "reservations"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReservationStatusChange records a step in a reservation's lifecycle and
+// the user who performed it
+type ReservationStatusChange struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	ReservationID uuid.UUID `gorm:"type:uuid;not null;index" json:"reservation_id"`
+	FromStatus    string    `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus      string    `gorm:"type:varchar(20);not null" json:"to_status"`
+	ChangedByID   uuid.UUID `gorm:"type:uuid;not null" json:"changed_by_id"`
+	ChangedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"changed_at"`
+
+	Reservation Reservation `gorm:"foreignKey:ReservationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (c *ReservationStatusChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	if c.ChangedAt.IsZero() {
+		c.ChangedAt = time.Now()
+	}
+
+	return nil
+}
+
+func (ReservationStatusChange) TableName() string {
+	return "reservation_status_changes"
+}
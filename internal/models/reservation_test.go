@@ -0,0 +1,51 @@
+package models
+
+import "testing"
+
+func TestReservationCanTransitionTo(t *testing.T) {
+	statuses := []string{
+		ReservationStatusPending,
+		ReservationStatusConfirmed,
+		ReservationStatusCheckedIn,
+		ReservationStatusCheckedOut,
+		ReservationStatusCancelled,
+		ReservationStatusNoShow,
+	}
+
+	// every allowed move; any other pair, including staying put, is refused
+	allowed := map[[2]string]bool{
+		{ReservationStatusPending, ReservationStatusConfirmed}:    true,
+		{ReservationStatusPending, ReservationStatusCancelled}:    true,
+		{ReservationStatusConfirmed, ReservationStatusCheckedIn}:  true,
+		{ReservationStatusConfirmed, ReservationStatusCancelled}:  true,
+		{ReservationStatusConfirmed, ReservationStatusNoShow}:     true,
+		{ReservationStatusCheckedIn, ReservationStatusCheckedOut}: true,
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			reservation := &Reservation{Status: from}
+			want := allowed[[2]string{from, to}]
+			if got := reservation.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s allowed = %v; want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestReservationCanTransitionToUnknownStatus(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+	}{
+		{ReservationStatusPending, "archived"},
+		{"archived", ReservationStatusConfirmed},
+		{"", ReservationStatusConfirmed},
+	}
+
+	for _, tt := range tests {
+		if (&Reservation{Status: tt.from}).CanTransitionTo(tt.to) {
+			t.Errorf("%q -> %q is allowed", tt.from, tt.to)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// permissions checked by middleware.RequirePermission, seeded by the
+// 0004_roles_permissions migration
+const (
+	PermissionGuestsRead        = "guests:read"
+	PermissionGuestsWrite       = "guests:write"
+	PermissionRoomsRead         = "rooms:read"
+	PermissionRoomsManage       = "rooms:manage"
+	PermissionHousekeeping      = "housekeeping:write"
+	PermissionReservationsRead  = "reservations:read"
+	PermissionReservationsWrite = "reservations:write"
+	PermissionPaymentsRead      = "payments:read"
+	PermissionPaymentsWrite     = "payments:write"
+	PermissionPaymentsRefund    = "payments:refund"
+	PermissionUsersManage       = "users:manage"
+)
+
+// Role groups the permissions granted to the users whose User.Role holds
+// its name
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string       `gorm:"type:varchar(50);uniqueIndex;not null" json:"name"`
+	Description string       `gorm:"type:varchar(255);not null;default:''" json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions"`
+
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+
+	now := time.Now()
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = now
+	}
+	if r.UpdatedAt.IsZero() {
+		r.UpdatedAt = now
+	}
+
+	return nil
+}
+
+func (r *Role) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Code        string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"code"`
+	Description string    `gorm:"type:varchar(255);not null;default:''" json:"description"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
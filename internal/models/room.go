@@ -4,16 +4,54 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"gorm.io/gorm"
+)
+
+// room status flow driven by the front desk:
+// available -> occupied (check-in) -> dirty (check-out) -> available (cleaned)
+const (
+	RoomStatusAvailable   = "available"
+	RoomStatusOccupied    = "occupied"
+	RoomStatusDirty       = "dirty"
+	RoomStatusMaintenance = "maintenance"
+	RoomStatusRetired     = "retired"
 )
 
 type Room struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	Number    int       `gorm:"not null" json:"number"`
-	Type      string    `gorm:"type:varchar(50);not null" json:"type"`
-	Capacity  int       `gorm:"not null" json:"capacity"`
-	DailyRate float64   `gorm:"not null" json:"daily_rate"`
-	Status    string    `gorm:"not null;default:'available'" json:"status"`
+	ID        uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	Number    int          `gorm:"not null;uniqueIndex" json:"number"`
+	Type      string       `gorm:"type:varchar(50);not null" json:"type"`
+	Capacity  int          `gorm:"not null" json:"capacity"`
+	DailyRate money.Amount `gorm:"type:numeric(12,2);not null" json:"daily_rate"`
+	Currency  string       `gorm:"type:char(3);not null;default:'BRL'" json:"currency"`
+	Status    string       `gorm:"not null;default:'available'" json:"status"`
 
 	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
+
+func (r *Room) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+
+	now := time.Now()
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = now
+	}
+	if r.UpdatedAt.IsZero() {
+		r.UpdatedAt = now
+	}
+
+	return nil
+}
+
+func (r *Room) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+func (Room) TableName() string {
+	return "rooms"
+}
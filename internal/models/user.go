@@ -14,8 +14,21 @@ type User struct {
 	PasswordHash string    `gorm:"type:varchar(255);not null" json:"-"`
 	Role         string    `gorm:"type:varchar(255);not null;default:'user'" json:"role"`
 	Status       bool      `gorm:"not null;default:true" json:"status"`
-	CreatedAt    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	// FailedLoginAttempts counts the wrong passwords since the last
+	// successful login, starting over when LastFailedLoginAt is older than
+	// the throttle window; LockedUntil blocks logins while it is in the future
+	FailedLoginAttempts int        `gorm:"not null;default:0" json:"-"`
+	LastFailedLoginAt   *time.Time `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	// TOTPSecret is set at enrollment and only checked once TOTPEnabled
+	TOTPSecret   string `gorm:"type:varchar(64);not null;default:''" json:"-"`
+	TOTPEnabled  bool   `gorm:"not null;default:false" json:"two_factor_enabled"`
+	TOTPLastStep *int64 `json:"-"`
+	// TwoFactorChallengeID identifies the challenge of the last password
+	// login, until a two-factor login uses it
+	TwoFactorChallengeID *uuid.UUID `gorm:"type:uuid" json:"-"`
+	CreatedAt            time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt            time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
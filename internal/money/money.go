@@ -0,0 +1,194 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code used when none is given
+const DefaultCurrency = "BRL"
+
+// Amount is a monetary value stored as an integer number of cents, so sums
+// of nightly rates and partial payments never accumulate rounding errors.
+// It is stored as numeric(12,2) and encoded in JSON as a decimal number.
+type Amount int64
+
+// Max is the largest amount a numeric(12,2) column holds, 9999999999.99
+const Max Amount = 999_999_999_999
+
+// ErrOutOfRange is returned for a result larger than Max in either sign
+var ErrOutOfRange = errors.New("amount out of range")
+
+func FromCents(cents int64) Amount {
+	return Amount(cents)
+}
+
+// Parse reads a decimal value such as "150", "150.5" or "150.50". More than
+// two decimal places are rejected instead of being rounded.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	units, fraction, hasFraction := strings.Cut(s, ".")
+	if units == "" || !isDigits(units) || (hasFraction && (fraction == "" || !isDigits(fraction))) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("amount %q has more than two decimal places", s)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if Amount(cents) > Max {
+		return 0, fmt.Errorf("amount %q is out of range", s)
+	}
+	if negative {
+		cents = -cents
+	}
+
+	return Amount(cents), nil
+}
+
+func (a Amount) Cents() int64 {
+	return int64(a)
+}
+
+// Mul multiplies the amount by a whole quantity, e.g. a nightly rate by
+// the number of nights. It fails with ErrOutOfRange instead of returning a
+// product that can't be stored.
+func (a Amount) Mul(n int) (Amount, error) {
+	if n != 0 && a.abs() > Max/Amount(abs(n)) {
+		return 0, ErrOutOfRange
+	}
+	return a * Amount(n), nil
+}
+
+func (a Amount) abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (a Amount) String() string {
+	cents := int64(a)
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts both a JSON number (150.50) and a string ("150.50")
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case int64:
+		*a = Amount(v * 100)
+		return nil
+	case float64:
+		*a = Amount(math.Round(v * 100))
+		return nil
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money.Amount", src)
+	}
+}
+
+func (a *Amount) scanString(s string) error {
+	// numeric columns may come back with trailing zeros beyond the scale
+	if units, fraction, ok := strings.Cut(s, "."); ok && len(fraction) > 2 {
+		fraction = strings.TrimRight(fraction, "0")
+		s = units
+		if fraction != "" {
+			s += "." + fraction
+		}
+	}
+
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+// IsCurrencyCode reports whether code looks like an ISO 4217 code
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 'A' || code[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
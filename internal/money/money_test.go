@@ -0,0 +1,227 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr bool
+	}{
+		{input: "150", want: 15000},
+		{input: "150.5", want: 15050},
+		{input: "150.50", want: 15050},
+		{input: "0.01", want: 1},
+		{input: " 42.00 ", want: 4200},
+		{input: "+3.10", want: 310},
+		{input: "-3.10", want: -310},
+		{input: "0", want: 0},
+		{input: "150.505", wantErr: true},
+		{input: "150.", wantErr: true},
+		{input: ".50", wantErr: true},
+		{input: "1,50", wantErr: true},
+		{input: "1e3", wantErr: true},
+		{input: "--1", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "9999999999.99", want: Max},
+		{input: "-9999999999.99", want: -Max},
+		{input: "10000000000", wantErr: true},
+		{input: "99999999999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %v; want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) returned %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %d cents; want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		amount  Amount
+		n       int
+		want    Amount
+		wantErr bool
+	}{
+		{amount: 15050, n: 3, want: 45150},
+		{amount: 15050, n: 0, want: 0},
+		{amount: -100, n: 2, want: -200},
+		{amount: Max, n: 1, want: Max},
+		{amount: Max / 2, n: 2, want: Max - 1},
+		{amount: Max/2 + 1, n: 2, wantErr: true},
+		{amount: -Max, n: 2, wantErr: true},
+		{amount: 1, n: math.MaxInt, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.amount.Mul(tt.n)
+		if tt.wantErr {
+			if !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("%d.Mul(%d) = %d, %v; want %v", tt.amount, tt.n, got, err, ErrOutOfRange)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%d.Mul(%d) = %d, %v; want %d", tt.amount, tt.n, got, err, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{0, "0.00"},
+		{1, "0.01"},
+		{15050, "150.50"},
+		{-5, "-0.05"},
+		{-12345, "-123.45"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q; want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Amount
+		wantErr bool
+	}{
+		{name: "nil", src: nil, want: 0},
+		{name: "numeric bytes", src: []byte("150.50"), want: 15050},
+		{name: "numeric string", src: "7.05", want: 705},
+		{name: "trailing zeros beyond the scale", src: "10.5000", want: 1050},
+		{name: "whole numeric with zero scale", src: "10.000", want: 1000},
+		{name: "int64", src: int64(12), want: 1200},
+		{name: "float64 rounds to cents", src: 0.1 + 0.2, want: 30},
+		{name: "negative", src: "-2.50", want: -250},
+		{name: "extra precision", src: "1.005", wantErr: true},
+		{name: "not a number", src: "abc", wantErr: true},
+		{name: "unsupported type", src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Amount(-1)
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Scan(%v) = %v; want an error", tt.src, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v) returned %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %d cents; want %d", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	for _, amount := range []Amount{0, 1, 99, 15050, -310} {
+		value, err := amount.Value()
+		if err != nil {
+			t.Fatalf("Value() returned %v", err)
+		}
+
+		var scanned Amount
+		if err := scanned.Scan(value); err != nil {
+			t.Fatalf("Scan(%v) returned %v", value, err)
+		}
+		if scanned != amount {
+			t.Errorf("round trip of %d cents gave %d", amount, scanned)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr bool
+	}{
+		{input: `150.50`, want: 15050},
+		{input: `"150.50"`, want: 15050},
+		{input: `12`, want: 1200},
+		{input: `null`, want: 0},
+		{input: `1.999`, wantErr: true},
+		{input: `"abc"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var got Amount
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %v; want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) returned %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %d cents; want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	encoded, err := json.Marshal(struct {
+		Total Amount `json:"total"`
+	}{Total: 15050})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{"total":150.50}` {
+		t.Errorf("Marshal = %s; want {\"total\":150.50}", encoded)
+	}
+}
+
+func TestIsCurrencyCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"BRL", true},
+		{"USD", true},
+		{"brl", false},
+		{"BR", false},
+		{"BRLL", false},
+		{"B1L", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCurrencyCode(tt.code); got != tt.want {
+			t.Errorf("IsCurrencyCode(%q) = %v; want %v", tt.code, got, tt.want)
+		}
+	}
+}
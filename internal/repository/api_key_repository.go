@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// apiKeyUsageResolution is how stale last_used_at may get before a request
+// updates it, so busy integrations don't write on every call
+const apiKeyUsageResolution = time.Minute
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAll(ctx context.Context) ([]models.APIKey, error)
+	// Revoke disables a key, returning errors.ErrConflict if it already was
+	Revoke(ctx context.Context, id uuid.UUID) error
+	// TouchLastUsed records that the key was used at the given time
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (a *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if err := a.db.WithContext(ctx).Omit("CreatedBy", "Scopes.*").Create(key).Error; err != nil {
+		return errors.Wrap(err, "failed to create API key")
+	}
+	return nil
+}
+
+func (a *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	result := a.db.WithContext(ctx).Preload("Scopes").First(&key, "key_hash = ?", hash)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get API key")
+	}
+
+	return &key, nil
+}
+
+func (a *apiKeyRepository) ListAll(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := a.db.WithContext(ctx).Preload("Scopes").Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list API keys")
+	}
+	return keys, nil
+}
+
+func (a *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	result := a.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to revoke API key")
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := a.db.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return errors.Wrap(err, "failed to get API key")
+		}
+		if count == 0 {
+			return errors.ErrNotFound
+		}
+		return errors.ErrConflict
+	}
+
+	return nil
+}
+
+func (a *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := a.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-apiKeyUsageResolution)).
+		Update("last_used_at", at).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to update API key usage")
+	}
+	return nil
+}
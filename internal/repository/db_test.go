@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/database"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB connects to the postgres database in TEST_DATABASE_URL and migrates
+// a schema of its own, dropped when the test ends, so tests never see each
+// other's rows. The test is skipped when the variable is unset.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin := openTestDB(t, dsn)
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("dropping schema: %v", err)
+		}
+	})
+
+	db := openTestDB(t, withSearchPath(t, dsn, schema))
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+
+	return db
+}
+
+func openTestDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("getting the underlying *sql.DB: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+// withSearchPath points every connection opened with dsn at schema; both the
+// URL and the key=value forms of the connection string are accepted
+func withSearchPath(t *testing.T, dsn, schema string) string {
+	t.Helper()
+
+	if !strings.Contains(dsn, "://") {
+		return fmt.Sprintf("%s search_path=%s", dsn, schema)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("parsing TEST_DATABASE_URL: %v", err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// seedRoom stores an available room with a unique number
+func seedRoom(t *testing.T, db *gorm.DB, number int) *models.Room {
+	t.Helper()
+
+	room := &models.Room{
+		Number:    number,
+		Type:      "double",
+		Capacity:  2,
+		DailyRate: money.Amount(10000),
+		Currency:  "BRL",
+		Status:    models.RoomStatusAvailable,
+	}
+	if err := db.Create(room).Error; err != nil {
+		t.Fatalf("seeding room: %v", err)
+	}
+
+	return room
+}
+
+// seedGuest stores a guest with a unique CPF and e-mail
+func seedGuest(t *testing.T, db *gorm.DB) *models.Guest {
+	t.Helper()
+
+	id := uuid.New()
+	guest := &models.Guest{
+		ID:          id,
+		Name:        "Test Guest",
+		Cpf:         strings.ReplaceAll(id.String(), "-", "")[:11],
+		DataNasc:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		Telefone:    "11999999999",
+		Email:       id.String() + "@example.com",
+		Observacoes: "",
+	}
+	if err := db.Create(guest).Error; err != nil {
+		t.Fatalf("seeding guest: %v", err)
+	}
+
+	return guest
+}
+
+// date returns midnight UTC of the given day in a fixed month
+func date(day int) time.Time {
+	return time.Date(2030, time.March, day, 0, 0, 0, 0, time.UTC)
+}
@@ -28,6 +28,9 @@ func NewGuestRepository(db *gorm.DB) GuestRepository {
 func (g *guestRepository) Create(ctx context.Context, guest *models.Guest) error {
 	result := g.db.WithContext(ctx).Create(guest)
 	if result.Error != nil {
+		if result.Error == gorm.ErrDuplicatedKey {
+			return errors.ErrAlreadyExists
+		}
 		return errors.Wrap(result.Error, "failed to create guest")
 	}
 
@@ -66,6 +69,9 @@ func (g *guestRepository) Update(ctx context.Context, guest *models.Guest) error
 	})
 
 	if result.Error != nil {
+		if result.Error == gorm.ErrDuplicatedKey {
+			return errors.ErrAlreadyExists
+		}
 		return errors.Wrap(result.Error, "failed to update guest")
 	}
 
@@ -80,6 +86,10 @@ func (g *guestRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	result := g.db.WithContext(ctx).Delete(&models.Guest{}, "id = ?", id)
 
 	if result.Error != nil {
+		// guests with reservations are kept for the booking history
+		if result.Error == gorm.ErrForeignKeyViolated {
+			return errors.ErrConflict
+		}
 		return errors.Wrap(result.Error, "failed to delete guest")
 	}
 
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *models.Invitation) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error)
+	GetByHash(ctx context.Context, hash string) (*models.Invitation, error)
+	ListAll(ctx context.Context) ([]models.Invitation, error)
+	// Revoke cancels a pending invitation, returning errors.ErrConflict if
+	// it was already accepted or revoked
+	Revoke(ctx context.Context, id uuid.UUID) error
+	// Accept creates user and consumes the invitation in one transaction,
+	// returning errors.ErrConflict if the invitation is no longer pending
+	Accept(ctx context.Context, id uuid.UUID, user *models.User) error
+}
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (i *invitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	if err := i.db.WithContext(ctx).Omit("InvitedBy").Create(invitation).Error; err != nil {
+		return errors.Wrap(err, "failed to create invitation")
+	}
+	return nil
+}
+
+func (i *invitationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error) {
+	var invitation models.Invitation
+	result := i.db.WithContext(ctx).First(&invitation, "id = ?", id)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get invitation")
+	}
+
+	return &invitation, nil
+}
+
+func (i *invitationRepository) GetByHash(ctx context.Context, hash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	result := i.db.WithContext(ctx).First(&invitation, "token_hash = ?", hash)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get invitation")
+	}
+
+	return &invitation, nil
+}
+
+func (i *invitationRepository) ListAll(ctx context.Context) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	if err := i.db.WithContext(ctx).Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list invitations")
+	}
+	return invitations, nil
+}
+
+func (i *invitationRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	result := i.db.WithContext(ctx).Model(&models.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to revoke invitation")
+	}
+
+	if result.RowsAffected == 0 {
+		if _, err := i.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return errors.ErrConflict
+	}
+
+	return nil
+}
+
+func (i *invitationRepository) Accept(ctx context.Context, id uuid.UUID, user *models.User) error {
+	return i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+			Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to accept invitation")
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrConflict
+		}
+
+		if err := tx.Create(user).Error; err != nil {
+			if err == gorm.ErrDuplicatedKey {
+				return errors.ErrAlreadyExists
+			}
+			return errors.Wrap(err, "failed to create user")
+		}
+
+		return nil
+	})
+}
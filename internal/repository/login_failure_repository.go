@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// LoginFailureRepository tracks the failed logins of e-mails that belong to
+// no account
+type LoginFailureRepository interface {
+	GetByEmail(ctx context.Context, email string) (*models.LoginFailure, error)
+	// Record increments the failed login counter of email and returns its
+	// new value. A counter whose last failure was before resetBefore starts
+	// over at 1.
+	Record(ctx context.Context, email string, resetBefore time.Time) (int, error)
+	LockUntil(ctx context.Context, email string, until time.Time) error
+	// DeleteStale forgets the e-mails whose last failure was before before
+	// and that aren't locked anymore, returning how many were deleted
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+type loginFailureRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginFailureRepository(db *gorm.DB) LoginFailureRepository {
+	return &loginFailureRepository{db: db}
+}
+
+func (l *loginFailureRepository) GetByEmail(ctx context.Context, email string) (*models.LoginFailure, error) {
+	var failure models.LoginFailure
+	result := l.db.WithContext(ctx).First(&failure, "email = ?", email)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get login failures")
+	}
+
+	return &failure, nil
+}
+
+func (l *loginFailureRepository) Record(ctx context.Context, email string, resetBefore time.Time) (int, error) {
+	now := time.Now()
+	var attempts []int
+	result := l.db.WithContext(ctx).Raw(
+		`INSERT INTO login_failures (email, attempts, last_failed_at, updated_at) VALUES (?, 1, ?, ?)
+		ON CONFLICT (email) DO UPDATE SET
+			attempts = CASE
+				WHEN login_failures.last_failed_at < ? THEN 1
+				ELSE login_failures.attempts + 1
+			END,
+			last_failed_at = EXCLUDED.last_failed_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING attempts`,
+		email, now, now, resetBefore,
+	).Scan(&attempts)
+
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "failed to record login failure")
+	}
+
+	if len(attempts) == 0 {
+		return 0, errors.ErrNotFound
+	}
+
+	return attempts[0], nil
+}
+
+func (l *loginFailureRepository) LockUntil(ctx context.Context, email string, until time.Time) error {
+	result := l.db.WithContext(ctx).Model(&models.LoginFailure{}).Where("email = ?", email).Updates(map[string]interface{}{
+		"locked_until": until,
+		"updated_at":   time.Now(),
+	})
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to lock login failures")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (l *loginFailureRepository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	result := l.db.WithContext(ctx).
+		Where("last_failed_at < ?", before).
+		Where("(locked_until IS NULL OR locked_until < ?)", time.Now()).
+		Delete(&models.LoginFailure{})
+
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "failed to delete stale login failures")
+	}
+
+	return result.RowsAffected, nil
+}
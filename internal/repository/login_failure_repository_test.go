@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+)
+
+func TestLoginFailureRecordWindow(t *testing.T) {
+	db := testDB(t)
+	repo := NewLoginFailureRepository(db)
+	ctx := context.Background()
+
+	// each step records a failure with the reset point relative to the
+	// previous failure
+	steps := []struct {
+		name         string
+		resetBefore  time.Duration
+		wantAttempts int
+	}{
+		{name: "first failure", resetBefore: -time.Hour, wantAttempts: 1},
+		{name: "within the window", resetBefore: -time.Hour, wantAttempts: 2},
+		{name: "again within the window", resetBefore: -time.Hour, wantAttempts: 3},
+		{name: "after the window", resetBefore: time.Hour, wantAttempts: 1},
+		{name: "counting again", resetBefore: -time.Hour, wantAttempts: 2},
+	}
+
+	for _, step := range steps {
+		attempts, err := repo.Record(ctx, "nobody@example.com", time.Now().Add(step.resetBefore))
+		if err != nil {
+			t.Fatalf("%s: Record() error = %v", step.name, err)
+		}
+		if attempts != step.wantAttempts {
+			t.Errorf("%s: Record() = %d, want %d", step.name, attempts, step.wantAttempts)
+		}
+	}
+}
+
+func TestLoginFailureDeleteStale(t *testing.T) {
+	db := testDB(t)
+	repo := NewLoginFailureRepository(db)
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	failures := []struct {
+		email       string
+		lastFailed  time.Time
+		lockedUntil *time.Time
+		wantKept    bool
+	}{
+		{email: "stale@example.com", lastFailed: now.Add(-2 * time.Hour)},
+		{email: "lock-expired@example.com", lastFailed: now.Add(-2 * time.Hour), lockedUntil: &earlier},
+		{email: "still-locked@example.com", lastFailed: now.Add(-2 * time.Hour), lockedUntil: &later, wantKept: true},
+		{email: "recent@example.com", lastFailed: now.Add(-time.Minute), wantKept: true},
+	}
+	for _, failure := range failures {
+		err := db.Create(&models.LoginFailure{
+			Email:        failure.email,
+			Attempts:     3,
+			LastFailedAt: failure.lastFailed,
+			LockedUntil:  failure.lockedUntil,
+		}).Error
+		if err != nil {
+			t.Fatalf("seeding %s: %v", failure.email, err)
+		}
+	}
+
+	deleted, err := repo.DeleteStale(context.Background(), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteStale() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteStale() = %d, want 2", deleted)
+	}
+
+	for _, failure := range failures {
+		_, err := repo.GetByEmail(context.Background(), failure.email)
+		if kept := err == nil; kept != failure.wantKept {
+			t.Errorf("%s: kept = %v, want %v (error %v)", failure.email, kept, failure.wantKept, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository interface {
+	// Create stores token and invalidates the user's previous unused tokens
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	GetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+	// MarkUsed consumes the token, returning errors.ErrConflict if it was
+	// already used
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (p *passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to invalidate password reset tokens")
+		}
+
+		if err := tx.Omit("User").Create(token).Error; err != nil {
+			return errors.Wrap(err, "failed to create password reset token")
+		}
+
+		return nil
+	})
+}
+
+func (p *passwordResetRepository) GetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	result := p.db.WithContext(ctx).First(&token, "token_hash = ?", hash)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get password reset token")
+	}
+
+	return &token, nil
+}
+
+func (p *passwordResetRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	result := p.db.WithContext(ctx).
+		Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to mark password reset token as used")
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRepository interface {
+	// Create records the payment only if the reservation still accepts
+	// payments, failing with a *ReservationStatusError otherwise, and if the
+	// paid total stays within its TotalAmount, returning errors.ErrConflict
+	// otherwise. Both are checked under a lock of the reservation.
+	Create(ctx context.Context, payment *models.Payment) error
+	ListByReservation(ctx context.Context, reservationID uuid.UUID) ([]models.Payment, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (p *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// locking the reservation serializes concurrent payments and status
+		// changes, so neither check below can be raced
+		var reservation models.Reservation
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reservation, "id = ?", payment.ReservationID)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return errors.ErrNotFound
+			}
+			return errors.Wrap(result.Error, "failed to lock reservation")
+		}
+		if !reservation.AcceptsPayments() {
+			return &ReservationStatusError{Status: reservation.Status}
+		}
+
+		var paid money.Amount
+		result = tx.Model(&models.Payment{}).
+			Select("COALESCE(SUM(amount_paid), 0)").
+			Where("reservation_id = ? AND payment_status = ?", payment.ReservationID, models.PaymentStatusPaid).
+			Scan(&paid)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to sum reservation payments")
+		}
+		if paid+payment.AmountPaid > reservation.TotalAmount {
+			return errors.ErrConflict
+		}
+
+		if err := tx.Omit(clause.Associations).Create(payment).Error; err != nil {
+			return errors.Wrap(err, "failed to create payment")
+		}
+
+		return nil
+	})
+}
+
+func (p *paymentRepository) ListByReservation(ctx context.Context, reservationID uuid.UUID) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := p.db.WithContext(ctx).
+		Where("reservation_id = ?", reservationID).
+		Order("payment_date, created_at").
+		Find(&payments).Error
+
+	return payments, err
+}
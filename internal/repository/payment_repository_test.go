@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"gorm.io/gorm"
+)
+
+// seedReservation stores a pending reservation of 300.00 in a new room
+func seedReservation(t *testing.T, db *gorm.DB, roomNumber int) *models.Reservation {
+	t.Helper()
+
+	reservation, change := newReservation(seedGuest(t, db), seedRoom(t, db, roomNumber), date(10), date(13), models.ReservationStatusPending)
+	if err := NewReservationRepository(db).Create(context.Background(), reservation, change); err != nil {
+		t.Fatalf("seeding reservation: %v", err)
+	}
+
+	return reservation
+}
+
+func newPayment(reservationID uuid.UUID, amount money.Amount, status string) *models.Payment {
+	return &models.Payment{
+		ReservationID: reservationID,
+		AmountPaid:    amount,
+		Currency:      "BRL",
+		PaymentDate:   date(10),
+		PaymentMethod: models.PaymentMethodPix,
+		PaymentStatus: status,
+	}
+}
+
+func TestPaymentCreateBalance(t *testing.T) {
+	db := testDB(t)
+	repo := NewPaymentRepository(db)
+	reservation := seedReservation(t, db, 1)
+
+	// the steps run in order against the same 300.00 reservation
+	steps := []struct {
+		name     string
+		amount   money.Amount
+		status   string
+		wantErr  error
+		wantPaid money.Amount
+	}{
+		{name: "first payment", amount: 10000, status: models.PaymentStatusPaid, wantPaid: 10000},
+		{name: "more than the balance", amount: 20001, status: models.PaymentStatusPaid, wantErr: apperrors.ErrConflict, wantPaid: 10000},
+		{name: "pending payment of the balance", amount: 20000, status: models.PaymentStatusPending, wantPaid: 10000},
+		{name: "pending payments leave the balance open", amount: 15000, status: models.PaymentStatusPaid, wantPaid: 25000},
+		{name: "exactly the balance", amount: 5000, status: models.PaymentStatusPaid, wantPaid: 30000},
+		{name: "one cent over a settled reservation", amount: 1, status: models.PaymentStatusPaid, wantErr: apperrors.ErrConflict, wantPaid: 30000},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			err := repo.Create(context.Background(), newPayment(reservation.ID, step.amount, step.status))
+			if !errors.Is(err, step.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, step.wantErr)
+			}
+
+			if paid := paidTotal(t, db, reservation.ID); paid != step.wantPaid {
+				t.Errorf("paid total = %s, want %s", paid, step.wantPaid)
+			}
+		})
+	}
+}
+
+func TestPaymentCreateUnknownReservation(t *testing.T) {
+	db := testDB(t)
+	repo := NewPaymentRepository(db)
+
+	err := repo.Create(context.Background(), newPayment(uuid.New(), 100, models.PaymentStatusPaid))
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("Create() error = %v, want %v", err, apperrors.ErrNotFound)
+	}
+}
+
+func TestPaymentCreateCancelledReservation(t *testing.T) {
+	db := testDB(t)
+	repo := NewPaymentRepository(db)
+	reservation := seedReservation(t, db, 1)
+
+	// cancelled after the caller read it, the reservation is only checked
+	// again under the lock
+	err := db.Model(&models.Reservation{}).Where("id = ?", reservation.ID).Update("status", models.ReservationStatusCancelled).Error
+	if err != nil {
+		t.Fatalf("cancelling reservation: %v", err)
+	}
+
+	err = repo.Create(context.Background(), newPayment(reservation.ID, 100, models.PaymentStatusPaid))
+	var statusErr *ReservationStatusError
+	if !errors.As(err, &statusErr) || statusErr.Status != models.ReservationStatusCancelled {
+		t.Fatalf("Create() error = %v, want a cancelled *ReservationStatusError", err)
+	}
+	if paid := paidTotal(t, db, reservation.ID); paid != 0 {
+		t.Errorf("paid total = %s, want 0", paid)
+	}
+}
+
+func TestPaymentCreateConcurrent(t *testing.T) {
+	db := testDB(t)
+	repo := NewPaymentRepository(db)
+	reservation := seedReservation(t, db, 1)
+
+	// ten payments of 50.00 race for a balance that only fits six
+	const payments = 10
+	errs := make([]error, payments)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < payments; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			payment := newPayment(reservation.ID, 5000, models.PaymentStatusPaid)
+			<-start
+			errs[i] = repo.Create(context.Background(), payment)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, apperrors.ErrConflict):
+			t.Errorf("Create() error = %v, want nil or %v", err, apperrors.ErrConflict)
+		}
+	}
+	if created != 6 {
+		t.Errorf("%d payments succeeded, want 6", created)
+	}
+
+	if paid := paidTotal(t, db, reservation.ID); paid != reservation.TotalAmount {
+		t.Errorf("paid total = %s, want %s", paid, reservation.TotalAmount)
+	}
+}
+
+func paidTotal(t *testing.T, db *gorm.DB, reservationID uuid.UUID) money.Amount {
+	t.Helper()
+
+	var paid money.Amount
+	err := db.Model(&models.Payment{}).
+		Select("COALESCE(SUM(amount_paid), 0)").
+		Where("reservation_id = ? AND payment_status = ?", reservationID, models.PaymentStatusPaid).
+		Scan(&paid).Error
+	if err != nil {
+		t.Fatalf("summing payments: %v", err)
+	}
+
+	return paid
+}
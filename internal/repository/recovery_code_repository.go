@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	// Replace deletes the user's recovery codes and stores the given hashes
+	// in their place
+	Replace(ctx context.Context, userID uuid.UUID, hashes []string) error
+	// Use consumes an unused code of the user, returning false if there is
+	// no such code
+	Use(ctx context.Context, userID uuid.UUID, hash string) (bool, error)
+	CountUnused(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteAll(ctx context.Context, userID uuid.UUID) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.RecoveryCode{}, "user_id = ?", userID).Error; err != nil {
+			return errors.Wrap(err, "failed to delete recovery codes")
+		}
+
+		codes := make([]models.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, models.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+
+		if err := tx.Omit("User").Create(&codes).Error; err != nil {
+			return errors.Wrap(err, "failed to create recovery codes")
+		}
+
+		return nil
+	})
+}
+
+func (r *recoveryCodeRepository) Use(ctx context.Context, userID uuid.UUID, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "failed to use recovery code")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *recoveryCodeRepository) CountUnused(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count recovery codes")
+	}
+
+	return count, nil
+}
+
+func (r *recoveryCodeRepository) DeleteAll(ctx context.Context, userID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&models.RecoveryCode{}, "user_id = ?", userID).Error; err != nil {
+		return errors.Wrap(err, "failed to delete recovery codes")
+	}
+	return nil
+}
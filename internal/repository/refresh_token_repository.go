@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// Rotate revokes old and stores its replacement in one transaction,
+	// returning errors.ErrConflict if old was already revoked
+	Rotate(ctx context.Context, old *models.RefreshToken, replacement *models.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+	RevokeAllForUserExcept(ctx context.Context, userID, keepFamilyID uuid.UUID) error
+	// IsFamilyActive reports whether the session still holds a usable token
+	IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, error)
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	result := r.db.WithContext(ctx).Omit("User").Create(token)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to create refresh token")
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	result := r.db.WithContext(ctx).First(&token, "token_hash = ?", hash)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get refresh token")
+	}
+
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) Rotate(ctx context.Context, old *models.RefreshToken, replacement *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Create(replacement).Error; err != nil {
+			return errors.Wrap(err, "failed to create refresh token")
+		}
+
+		// the revoked_at guard makes a concurrent second use of old fail
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"replaced_by_id": replacement.ID,
+			})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to revoke refresh token")
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrConflict
+		}
+
+		return nil
+	})
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to revoke refresh token family")
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to revoke user refresh tokens")
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeAllForUserExcept(ctx context.Context, userID, keepFamilyID uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to revoke user refresh tokens")
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	var count int64
+	result := r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "failed to check refresh token family")
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReservationRepository interface {
+	// Create books the room only if no active reservation overlaps the
+	// requested dates, returning errors.ErrConflict otherwise
+	Create(ctx context.Context, reservation *models.Reservation, change *models.ReservationStatusChange) error
+	ListAll(ctx context.Context) ([]models.Reservation, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Reservation, error)
+	// UpdateStatus moves the reservation from change.FromStatus to
+	// change.ToStatus and records the change, returning errors.ErrConflict
+	// if the status was modified concurrently. A non-nil room update is
+	// applied to the reservation's room in the same transaction.
+	UpdateStatus(ctx context.Context, reservation *models.Reservation, change *models.ReservationStatusChange, room *RoomStatusUpdate) error
+	ListStatusChanges(ctx context.Context, reservationID uuid.UUID) ([]models.ReservationStatusChange, error)
+}
+
+// RoomStatusUpdate moves a room from From to To. The room is locked and,
+// when it isn't in From, the update fails with a *RoomStatusError unless
+// LeaveOthers is set, in which case the room keeps its status.
+type RoomStatusUpdate struct {
+	From        string
+	To          string
+	LeaveOthers bool
+}
+
+// RoomStatusError reports a room that isn't in the status an update needs
+type RoomStatusError struct {
+	Status string
+}
+
+func (e *RoomStatusError) Error() string {
+	return "room is " + e.Status
+}
+
+func (e *RoomStatusError) Is(target error) bool {
+	return target == errors.ErrConflict
+}
+
+// ReservationStatusError reports a reservation whose status doesn't allow
+// the change
+type ReservationStatusError struct {
+	Status string
+}
+
+func (e *ReservationStatusError) Error() string {
+	return "reservation is " + e.Status
+}
+
+func (e *ReservationStatusError) Is(target error) bool {
+	return target == errors.ErrConflict
+}
+
+type reservationRepository struct {
+	db *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+func (r *reservationRepository) Create(ctx context.Context, reservation *models.Reservation, change *models.ReservationStatusChange) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// locking the room row serializes concurrent bookings of the same room,
+		// so two requests can't both pass the overlap check below
+		var room models.Room
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, "id = ?", reservation.RoomID)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return errors.ErrNotFound
+			}
+			return errors.Wrap(result.Error, "failed to lock room")
+		}
+
+		var overlapping int64
+		result = tx.Model(&models.Reservation{}).
+			Where("room_id = ?", reservation.RoomID).
+			Where("status IN ?", models.ActiveReservationStatuses).
+			Where("check_in_date < ? AND check_out_date > ?", reservation.CheckOutDate, reservation.CheckInDate).
+			Count(&overlapping)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to check overlapping reservations")
+		}
+		if overlapping > 0 {
+			return errors.ErrConflict
+		}
+
+		if err := tx.Omit(clause.Associations).Create(reservation).Error; err != nil {
+			return errors.Wrap(err, "failed to create reservation")
+		}
+
+		change.ReservationID = reservation.ID
+		if err := tx.Omit(clause.Associations).Create(change).Error; err != nil {
+			return errors.Wrap(err, "failed to record reservation status")
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (r *reservationRepository) ListAll(ctx context.Context) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).
+		Preload("Guest").
+		Preload("Room").
+		Order("check_in_date").
+		Find(&reservations).Error
+
+	return reservations, err
+}
+
+func (r *reservationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Reservation, error) {
+	var reservation models.Reservation
+	result := r.db.WithContext(ctx).
+		Preload("Guest").
+		Preload("Room").
+		First(&reservation, "id = ?", id)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get reservation by ID")
+	}
+
+	return &reservation, nil
+}
+
+func (r *reservationRepository) UpdateStatus(ctx context.Context, reservation *models.Reservation, change *models.ReservationStatusChange, roomUpdate *RoomStatusUpdate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the room is locked first, in the same order as Create, so a
+		// concurrent check-in of the room waits for this one
+		if roomUpdate != nil {
+			var room models.Room
+			result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, "id = ?", reservation.RoomID)
+			if result.Error != nil {
+				if result.Error == gorm.ErrRecordNotFound {
+					return errors.ErrNotFound
+				}
+				return errors.Wrap(result.Error, "failed to lock room")
+			}
+			if room.Status != roomUpdate.From {
+				if !roomUpdate.LeaveOthers {
+					return &RoomStatusError{Status: room.Status}
+				}
+				roomUpdate = nil
+			}
+		}
+
+		result := tx.Model(&models.Reservation{}).
+			Where("id = ? AND status = ?", reservation.ID, change.FromStatus).
+			Updates(map[string]interface{}{
+				"status":     change.ToStatus,
+				"updated_at": change.ChangedAt,
+			})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to update reservation status")
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrConflict
+		}
+
+		change.ReservationID = reservation.ID
+		if err := tx.Omit(clause.Associations).Create(change).Error; err != nil {
+			return errors.Wrap(err, "failed to record reservation status")
+		}
+
+		if roomUpdate != nil {
+			result := tx.Model(&models.Room{}).
+				Where("id = ?", reservation.RoomID).
+				Updates(map[string]interface{}{
+					"status":     roomUpdate.To,
+					"updated_at": change.ChangedAt,
+				})
+			if result.Error != nil {
+				return errors.Wrap(result.Error, "failed to update room status")
+			}
+			if result.RowsAffected == 0 {
+				return errors.ErrNotFound
+			}
+			reservation.Room.Status = roomUpdate.To
+		}
+
+		reservation.Status = change.ToStatus
+		reservation.UpdatedAt = change.ChangedAt
+
+		return nil
+	})
+}
+
+func (r *reservationRepository) ListStatusChanges(ctx context.Context, reservationID uuid.UUID) ([]models.ReservationStatusChange, error) {
+	var changes []models.ReservationStatusChange
+	err := r.db.WithContext(ctx).
+		Where("reservation_id = ?", reservationID).
+		Order("changed_at").
+		Find(&changes).Error
+
+	return changes, err
+}
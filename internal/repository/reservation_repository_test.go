@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+)
+
+func newReservation(guest *models.Guest, room *models.Room, checkIn, checkOut time.Time, status string) (*models.Reservation, *models.ReservationStatusChange) {
+	reservation := &models.Reservation{
+		GuestID:      guest.ID,
+		RoomID:       room.ID,
+		CheckInDate:  checkIn,
+		CheckOutDate: checkOut,
+		TotalAmount:  money.Amount(30000),
+		Currency:     "BRL",
+		Status:       status,
+	}
+	change := &models.ReservationStatusChange{
+		ToStatus:    status,
+		ChangedByID: uuid.New(),
+	}
+
+	return reservation, change
+}
+
+func TestReservationCreateOverlap(t *testing.T) {
+	db := testDB(t)
+	repo := NewReservationRepository(db)
+	guest := seedGuest(t, db)
+
+	tests := []struct {
+		name           string
+		existingStatus string
+		checkIn        int
+		checkOut       int
+		otherRoom      bool
+		wantErr        error
+	}{
+		{name: "same dates", existingStatus: models.ReservationStatusPending, checkIn: 10, checkOut: 13, wantErr: apperrors.ErrConflict},
+		{name: "starts during the stay", existingStatus: models.ReservationStatusConfirmed, checkIn: 12, checkOut: 15, wantErr: apperrors.ErrConflict},
+		{name: "ends during the stay", existingStatus: models.ReservationStatusConfirmed, checkIn: 8, checkOut: 11, wantErr: apperrors.ErrConflict},
+		{name: "inside the stay", existingStatus: models.ReservationStatusCheckedIn, checkIn: 11, checkOut: 12, wantErr: apperrors.ErrConflict},
+		{name: "around the stay", existingStatus: models.ReservationStatusPending, checkIn: 9, checkOut: 14, wantErr: apperrors.ErrConflict},
+
+		{name: "checks in on the check-out day", existingStatus: models.ReservationStatusConfirmed, checkIn: 13, checkOut: 15},
+		{name: "checks out on the check-in day", existingStatus: models.ReservationStatusConfirmed, checkIn: 7, checkOut: 10},
+		{name: "after the stay", existingStatus: models.ReservationStatusConfirmed, checkIn: 20, checkOut: 22},
+		{name: "another room", existingStatus: models.ReservationStatusConfirmed, checkIn: 10, checkOut: 13, otherRoom: true},
+		{name: "over a cancelled stay", existingStatus: models.ReservationStatusCancelled, checkIn: 10, checkOut: 13},
+		{name: "over a no-show", existingStatus: models.ReservationStatusNoShow, checkIn: 10, checkOut: 13},
+		{name: "over a finished stay", existingStatus: models.ReservationStatusCheckedOut, checkIn: 10, checkOut: 13},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			room := seedRoom(t, db, 100+i)
+
+			existing, change := newReservation(guest, room, date(10), date(13), tt.existingStatus)
+			if err := repo.Create(ctx, existing, change); err != nil {
+				t.Fatalf("creating existing reservation: %v", err)
+			}
+
+			target := room
+			if tt.otherRoom {
+				target = seedRoom(t, db, 900+i)
+			}
+			reservation, change := newReservation(guest, target, date(tt.checkIn), date(tt.checkOut), models.ReservationStatusPending)
+			err := repo.Create(ctx, reservation, change)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var count int64
+			if err := db.Model(&models.Reservation{}).Where("id = ?", reservation.ID).Count(&count).Error; err != nil {
+				t.Fatalf("counting reservations: %v", err)
+			}
+			want := int64(0)
+			if tt.wantErr == nil {
+				want = 1
+			}
+			if count != want {
+				t.Errorf("stored %d reservations, want %d", count, want)
+			}
+		})
+	}
+}
+
+func TestReservationCreateConcurrent(t *testing.T) {
+	db := testDB(t)
+	repo := NewReservationRepository(db)
+	guest := seedGuest(t, db)
+	room := seedRoom(t, db, 1)
+
+	const bookings = 10
+	errs := make([]error, bookings)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < bookings; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// every booking overlaps the others by at least one night
+			reservation, change := newReservation(guest, room, date(10+i%2), date(13+i%2), models.ReservationStatusPending)
+			<-start
+			errs[i] = repo.Create(context.Background(), reservation, change)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, apperrors.ErrConflict):
+			t.Errorf("Create() error = %v, want nil or %v", err, apperrors.ErrConflict)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d overlapping bookings succeeded, want 1", created)
+	}
+
+	var count int64
+	if err := db.Model(&models.Reservation{}).Where("room_id = ?", room.ID).Count(&count).Error; err != nil {
+		t.Fatalf("counting reservations: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("room has %d reservations, want 1", count)
+	}
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *models.Role) error
+	ListAll(ctx context.Context) ([]models.Role, error)
+	GetByName(ctx context.Context, name string) (*models.Role, error)
+	// ReplacePermissions sets the role's permissions to exactly permissions.
+	// It fails with errors.ErrConflict if no active user would be left
+	// with models.PermissionUsersManage.
+	ReplacePermissions(ctx context.Context, role *models.Role, permissions []models.Permission) error
+	ListPermissions(ctx context.Context) ([]models.Permission, error)
+	GetPermissionsByCodes(ctx context.Context, codes []string) ([]models.Permission, error)
+	// HasPermission reports whether the named role grants the permission code
+	HasPermission(ctx context.Context, roleName, code string) (bool, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *models.Role) error {
+	result := r.db.WithContext(ctx).Omit("Permissions.*").Create(role)
+	if result.Error != nil {
+		if result.Error == gorm.ErrDuplicatedKey {
+			return errors.ErrAlreadyExists
+		}
+		return errors.Wrap(result.Error, "failed to create role")
+	}
+
+	return nil
+}
+
+func (r *roleRepository) ListAll(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles).Error
+
+	return roles, err
+}
+
+func (r *roleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	result := r.db.WithContext(ctx).Preload("Permissions").First(&role, "name = ?", name)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get role by name")
+	}
+
+	return &role, nil
+}
+
+func (r *roleRepository) ReplacePermissions(ctx context.Context, role *models.Role, permissions []models.Permission) error {
+	return keepUserManager(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		err := tx.Omit("Permissions.*").Model(role).Association("Permissions").Replace(permissions)
+		if err != nil {
+			return errors.Wrap(err, "failed to replace role permissions")
+		}
+		return nil
+	})
+}
+
+func (r *roleRepository) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.WithContext(ctx).Order("code").Find(&permissions).Error
+
+	return permissions, err
+}
+
+func (r *roleRepository) GetPermissionsByCodes(ctx context.Context, codes []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	if len(codes) == 0 {
+		return permissions, nil
+	}
+
+	err := r.db.WithContext(ctx).Where("code IN ?", codes).Find(&permissions).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get permissions")
+	}
+
+	return permissions, nil
+}
+
+func (r *roleRepository) HasPermission(ctx context.Context, roleName, code string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("role_permissions").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("roles.name = ? AND permissions.code = ?", roleName, code).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check role permission")
+	}
+
+	return count > 0, nil
+}
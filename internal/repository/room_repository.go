@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoomRepository interface {
+	Create(ctx context.Context, room *models.Room) error
+	ListAll(ctx context.Context) ([]models.Room, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error)
+	// Update saves the room's editable fields but not its status, which
+	// check-in and check-out change concurrently. A non-nil status update is
+	// applied in the same transaction, see SetStatus.
+	Update(ctx context.Context, room *models.Room, status *RoomStatusUpdate) error
+	// SetStatus moves the room from update.From to update.To, failing with a
+	// *RoomStatusError if it is in any other status by then
+	SetStatus(ctx context.Context, id uuid.UUID, update RoomStatusUpdate) error
+	// ListAvailable returns the bookable rooms, neither retired nor under
+	// maintenance, with at least minCapacity places and no active
+	// reservation overlapping [checkIn, checkOut). An empty roomType
+	// matches every type.
+	ListAvailable(ctx context.Context, checkIn, checkOut time.Time, minCapacity int, roomType string) ([]models.Room, error)
+}
+
+type roomRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomRepository(db *gorm.DB) RoomRepository {
+	return &roomRepository{db: db}
+}
+
+func (r *roomRepository) Create(ctx context.Context, room *models.Room) error {
+	result := r.db.WithContext(ctx).Create(room)
+	if result.Error != nil {
+		if result.Error == gorm.ErrDuplicatedKey {
+			return errors.ErrAlreadyExists
+		}
+		return errors.Wrap(result.Error, "failed to create room")
+	}
+
+	return nil
+}
+
+func (r *roomRepository) ListAll(ctx context.Context) ([]models.Room, error) {
+	var rooms []models.Room
+	err := r.db.WithContext(ctx).Model(&models.Room{}).Order("number").Find(&rooms).Error
+
+	return rooms, err
+}
+
+func (r *roomRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error) {
+	var room models.Room
+	result := r.db.WithContext(ctx).First(&room, "id = ?", id)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(result.Error, "failed to get room by ID")
+	}
+
+	return &room, nil
+}
+
+func (r *roomRepository) Update(ctx context.Context, room *models.Room, status *RoomStatusUpdate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// returning refreshes the room, including the status it has now
+		result := tx.Model(room).Clauses(clause.Returning{}).Updates(map[string]interface{}{
+			"number":     room.Number,
+			"type":       room.Type,
+			"capacity":   room.Capacity,
+			"daily_rate": room.DailyRate,
+			"currency":   room.Currency,
+		})
+
+		if result.Error != nil {
+			if result.Error == gorm.ErrDuplicatedKey {
+				return errors.ErrAlreadyExists
+			}
+			return errors.Wrap(result.Error, "failed to update room")
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.ErrNotFound
+		}
+
+		if status != nil {
+			if err := setRoomStatus(tx, room.ID, *status); err != nil {
+				return err
+			}
+			room.Status = status.To
+		}
+
+		return nil
+	})
+}
+
+func (r *roomRepository) SetStatus(ctx context.Context, id uuid.UUID, update RoomStatusUpdate) error {
+	return setRoomStatus(r.db.WithContext(ctx), id, update)
+}
+
+// setRoomStatus only updates a room still in update.From, so a check-in or
+// check-out committed since the caller read the room isn't overwritten
+func setRoomStatus(tx *gorm.DB, id uuid.UUID, update RoomStatusUpdate) error {
+	result := tx.Model(&models.Room{}).
+		Where("id = ? AND status = ?", id, update.From).
+		Updates(map[string]interface{}{
+			"status":     update.To,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to update room status")
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var room models.Room
+	if err := tx.Select("status").First(&room, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.ErrNotFound
+		}
+		return errors.Wrap(err, "failed to get room status")
+	}
+
+	return &RoomStatusError{Status: room.Status}
+}
+
+func (r *roomRepository) ListAvailable(ctx context.Context, checkIn, checkOut time.Time, minCapacity int, roomType string) ([]models.Room, error) {
+	overlapping := r.db.Model(&models.Reservation{}).
+		Select("1").
+		Where("reservations.room_id = rooms.id").
+		Where("reservations.status IN ?", models.ActiveReservationStatuses).
+		Where("reservations.check_in_date < ? AND reservations.check_out_date > ?", checkOut, checkIn)
+
+	query := r.db.WithContext(ctx).
+		Model(&models.Room{}).
+		Where("status NOT IN ?", []string{models.RoomStatusRetired, models.RoomStatusMaintenance}).
+		Where("capacity >= ?", minCapacity).
+		Where("NOT EXISTS (?)", overlapping)
+
+	if roomType != "" {
+		query = query.Where("LOWER(type) = LOWER(?)", roomType)
+	}
+
+	var rooms []models.Room
+	if err := query.Order("daily_rate, number").Find(&rooms).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to list available rooms")
+	}
+
+	return rooms, nil
+}
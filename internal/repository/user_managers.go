@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// userManagersLockKey is the postgres advisory lock serializing the changes
+// that can take models.PermissionUsersManage away from someone
+const userManagersLockKey = 727_002
+
+// keepUserManager applies change in a transaction and rolls it back with
+// errors.ErrConflict if it leaves no active user whose role grants
+// models.PermissionUsersManage, since nobody could undo it afterwards
+func keepUserManager(db *gorm.DB, change func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", userManagersLockKey).Error; err != nil {
+			return errors.Wrap(err, "failed to lock user managers")
+		}
+
+		if err := change(tx); err != nil {
+			return err
+		}
+
+		var count int64
+		err := tx.Model(&models.User{}).
+			Joins("JOIN roles ON roles.name = users.role").
+			Joins("JOIN role_permissions ON role_permissions.role_id = roles.id").
+			Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+			Where("users.status = ? AND permissions.code = ?", true, models.PermissionUsersManage).
+			Count(&count).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to count user managers")
+		}
+		if count == 0 {
+			return errors.ErrConflict
+		}
+
+		return nil
+	})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/ruanv123/acme-hotel-api/internal/errors"
@@ -18,6 +19,28 @@ type UserRepository interface {
 	GrantAccess(ctx context.Context, id uuid.UUID) error
 	RevokeAccess(ctx context.Context, id uuid.UUID) error
 	// ====
+	SetRole(ctx context.Context, id uuid.UUID, role string) error
+	// RecordFailedLogin increments the failed login counter of the user and
+	// returns its new value. A counter whose last failure was before
+	// resetBefore starts over at 1.
+	RecordFailedLogin(ctx context.Context, id uuid.UUID, resetBefore time.Time) (int, error)
+	LockUntil(ctx context.Context, id uuid.UUID, until time.Time) error
+	// ResetFailedLogins clears the failed login counter and any lockout
+	ResetFailedLogins(ctx context.Context, id uuid.UUID) error
+	// SetTOTPSecret stores a new, not yet enabled, TOTP secret
+	SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, id uuid.UUID) error
+	// DisableTOTP turns two-factor authentication off and forgets the secret
+	DisableTOTP(ctx context.Context, id uuid.UUID) error
+	// UseTOTPStep records step as the last TOTP step used by the user,
+	// returning false if it is not newer than the previous one
+	UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
+	// SetTwoFactorChallenge makes challengeID the user's pending two-factor
+	// challenge, replacing any earlier one
+	SetTwoFactorChallenge(ctx context.Context, id, challengeID uuid.UUID) error
+	// UseTwoFactorChallenge clears the pending challenge if it is
+	// challengeID, returning false if it isn't (anymore)
+	UseTwoFactorChallenge(ctx context.Context, id, challengeID uuid.UUID) (bool, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -33,6 +56,9 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 func (u *userRepository) Create(ctx context.Context, user *models.User) error {
 	result := u.db.WithContext(ctx).Create(user)
 	if result.Error != nil {
+		if result.Error == gorm.ErrDuplicatedKey {
+			return errors.ErrAlreadyExists
+		}
 		return errors.Wrap(result.Error, "failed to create user")
 	}
 	return nil
@@ -52,9 +78,11 @@ func (u *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	return &user, nil
 }
 
+// GetByEmail matches the e-mail case-insensitively, like the unique index
+// on lower(email)
 func (u *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	result := u.db.WithContext(ctx).First(&user, "email = ?", email)
+	result := u.db.WithContext(ctx).First(&user, "lower(email) = lower(?)", email)
 
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
@@ -66,15 +94,28 @@ func (u *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return &user, nil
 }
 
+// Update writes the non-empty Email, Name and PasswordHash of user,
+// leaving the other columns untouched
 func (u *userRepository) Update(ctx context.Context, user *models.User) error {
-	result := u.db.WithContext(ctx).Model(user).Updates(map[string]interface{}{
-		"email":         user.Email,
-		"name":          user.Name,
-		"password_hash": user.PasswordHash,
-		"updated_at":    user.UpdatedAt,
-	})
+	changes := map[string]interface{}{
+		"updated_at": time.Now(),
+	}
+	if user.Email != "" {
+		changes["email"] = user.Email
+	}
+	if user.Name != "" {
+		changes["name"] = user.Name
+	}
+	if user.PasswordHash != "" {
+		changes["password_hash"] = user.PasswordHash
+	}
+
+	result := u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", user.ID).Updates(changes)
 
 	if result.Error != nil {
+		if result.Error == gorm.ErrDuplicatedKey {
+			return errors.ErrAlreadyExists
+		}
 		return errors.Wrap(result.Error, "failed to update user")
 	}
 
@@ -100,9 +141,180 @@ func (u *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 }
 
 func (u *userRepository) GrantAccess(ctx context.Context, id uuid.UUID) error {
-	panic("unimplemented")
+	return u.setStatus(ctx, id, true)
 }
 
 func (u *userRepository) RevokeAccess(ctx context.Context, id uuid.UUID) error {
-	panic("unimplemented")
+	return keepUserManager(u.db.WithContext(ctx), func(tx *gorm.DB) error {
+		return (&userRepository{db: tx}).setStatus(ctx, id, false)
+	})
+}
+
+func (u *userRepository) SetRole(ctx context.Context, id uuid.UUID, role string) error {
+	return keepUserManager(u.db.WithContext(ctx), func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"role":       role,
+			"updated_at": time.Now(),
+		})
+
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "failed to update user role")
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func (u *userRepository) RecordFailedLogin(ctx context.Context, id uuid.UUID, resetBefore time.Time) (int, error) {
+	var attempts []int
+	result := u.db.WithContext(ctx).Raw(
+		`UPDATE users SET
+			failed_login_attempts = CASE
+				WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1
+				ELSE failed_login_attempts + 1
+			END,
+			last_failed_login_at = ?
+		WHERE id = ? RETURNING failed_login_attempts`,
+		resetBefore, time.Now(), id,
+	).Scan(&attempts)
+
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "failed to record failed login")
+	}
+
+	if len(attempts) == 0 {
+		return 0, errors.ErrNotFound
+	}
+
+	return attempts[0], nil
+}
+
+func (u *userRepository) LockUntil(ctx context.Context, id uuid.UUID, until time.Time) error {
+	result := u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("locked_until", until)
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to lock user")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (u *userRepository) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	result := u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+		"locked_until":          nil,
+	})
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to unlock user")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (u *userRepository) SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	return u.updateTOTP(ctx, id, map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_enabled":   false,
+		"totp_last_step": nil,
+	})
+}
+
+func (u *userRepository) EnableTOTP(ctx context.Context, id uuid.UUID) error {
+	return u.updateTOTP(ctx, id, map[string]interface{}{
+		"totp_enabled": true,
+	})
+}
+
+func (u *userRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	return u.updateTOTP(ctx, id, map[string]interface{}{
+		"totp_secret":    "",
+		"totp_enabled":   false,
+		"totp_last_step": nil,
+	})
+}
+
+func (u *userRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	result := u.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", id, step).
+		Update("totp_last_step", step)
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "failed to record TOTP step")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (u *userRepository) SetTwoFactorChallenge(ctx context.Context, id, challengeID uuid.UUID) error {
+	result := u.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", id).
+		Update("two_factor_challenge_id", challengeID)
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to store two-factor challenge")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (u *userRepository) UseTwoFactorChallenge(ctx context.Context, id, challengeID uuid.UUID) (bool, error) {
+	result := u.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND two_factor_challenge_id = ?", id, challengeID).
+		Update("two_factor_challenge_id", nil)
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "failed to use two-factor challenge")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (u *userRepository) updateTOTP(ctx context.Context, id uuid.UUID, changes map[string]interface{}) error {
+	changes["updated_at"] = time.Now()
+	result := u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(changes)
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to update two-factor settings")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (u *userRepository) setStatus(ctx context.Context, id uuid.UUID, status bool) error {
+	result := u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	})
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to update user status")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
 }
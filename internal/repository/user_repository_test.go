@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+)
+
+func TestUserTwoFactorChallenge(t *testing.T) {
+	db := testDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{Name: "Ana", Email: "ana@example.com", PasswordHash: "x"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("seeding user: %v", err)
+	}
+
+	replaced, current := uuid.New(), uuid.New()
+	for _, challengeID := range []uuid.UUID{replaced, current} {
+		if err := repo.SetTwoFactorChallenge(ctx, user.ID, challengeID); err != nil {
+			t.Fatalf("SetTwoFactorChallenge() error = %v", err)
+		}
+	}
+
+	// each step uses a challenge, in order
+	steps := []struct {
+		name        string
+		challengeID uuid.UUID
+		wantUsed    bool
+	}{
+		{name: "replaced challenge", challengeID: replaced, wantUsed: false},
+		{name: "current challenge", challengeID: current, wantUsed: true},
+		{name: "current challenge again", challengeID: current, wantUsed: false},
+	}
+
+	for _, step := range steps {
+		used, err := repo.UseTwoFactorChallenge(ctx, user.ID, step.challengeID)
+		if err != nil {
+			t.Fatalf("%s: UseTwoFactorChallenge() error = %v", step.name, err)
+		}
+		if used != step.wantUsed {
+			t.Errorf("%s: UseTwoFactorChallenge() = %v, want %v", step.name, used, step.wantUsed)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+)
+
+const APIKeyContextKey contextKey = "api_key"
+
+// apiKeyPrefix starts every API key so leaked keys are easy to recognize
+const apiKeyPrefix = "ahk_"
+
+// apiKeyDisplayLength is how many leading characters of a key are stored in
+// clear to tell the keys apart
+const apiKeyDisplayLength = 12
+
+var (
+	ErrInvalidAPIKey        = errors.WithCode(errors.ErrUnauthenticated, "INVALID_API_KEY", "invalid API key")
+	ErrAPIKeyAlreadyRevoked = fmt.Errorf("%w: API key was already revoked", errors.ErrConflict)
+)
+
+// APIKeyInput carries the key an admin mints for an integration
+type APIKeyInput struct {
+	Name   string
+	Scopes []string
+	// ExpiresAt is optional; keys without it are valid until revoked
+	ExpiresAt *time.Time
+}
+
+type APIKeyService interface {
+	// Create mints a key acting on behalf of actorID and returns it with
+	// its plain text value, which can't be recovered afterwards
+	Create(ctx context.Context, actorID uuid.UUID, input APIKeyInput) (*models.APIKey, string, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	// Authenticate returns the key and the user it acts for, or
+	// ErrInvalidAPIKey
+	Authenticate(ctx context.Context, key string) (*models.APIKey, *models.User, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	roleRepo   repository.RoleRepository
+	userRepo   repository.UserRepository
+}
+
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	roleRepo repository.RoleRepository,
+	userRepo repository.UserRepository,
+) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		roleRepo:   roleRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *apiKeyService) Create(ctx context.Context, actorID uuid.UUID, input APIKeyInput) (*models.APIKey, string, error) {
+	input.Name = strings.TrimSpace(input.Name)
+
+	if input.Name == "" {
+		return nil, "", errors.NewValidationError("name", "is required")
+	}
+	if len(input.Name) > 100 {
+		return nil, "", errors.NewValidationError("name", "must be at most 100 bytes")
+	}
+	if len(input.Scopes) == 0 {
+		return nil, "", errors.NewValidationError("scopes", "must grant at least one permission")
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", errors.NewValidationError("expires_at", "must be in the future")
+	}
+
+	scopes, err := s.roleRepo.GetPermissionsByCodes(ctx, input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	known := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		known[scope.Code] = true
+	}
+	for _, code := range input.Scopes {
+		if !known[code] {
+			return nil, "", errors.NewValidationError("scopes", "unknown permission "+code)
+		}
+		// integrations never manage staff accounts
+		if code == models.PermissionUsersManage {
+			return nil, "", errors.NewValidationError("scopes", "API keys can't be granted "+code)
+		}
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := apiKeyPrefix + token
+
+	key := &models.APIKey{
+		Name:        input.Name,
+		Prefix:      plain[:apiKeyDisplayLength],
+		KeyHash:     hashToken(plain),
+		CreatedByID: actorID,
+		Scopes:      scopes,
+		ExpiresAt:   input.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plain, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context) ([]models.APIKey, error) {
+	return s.apiKeyRepo.ListAll(ctx)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
+	err := s.apiKeyRepo.Revoke(ctx, id)
+	if stderrors.Is(err, errors.ErrConflict) {
+		return ErrAPIKeyAlreadyRevoked
+	}
+	return err
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, plain string) (*models.APIKey, *models.User, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashToken(plain))
+	if err != nil {
+		if stderrors.Is(err, errors.ErrNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	// a key stops working along with the account of its creator
+	user, err := s.userRepo.GetByID(ctx, key.CreatedByID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.Status {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+		return nil, nil, err
+	}
+
+	return key, user, nil
+}
+
+func WithAPIKeyContext(ctx context.Context, key *models.APIKey) context.Context {
+	return context.WithValue(ctx, APIKeyContextKey, key)
+}
+
+// APIKeyFromContext returns the API key the request was authenticated
+// with, if it wasn't a user session
+func APIKeyFromContext(ctx context.Context) (*models.APIKey, bool) {
+	key, ok := ctx.Value(APIKeyContextKey).(*models.APIKey)
+	return key, ok
+}
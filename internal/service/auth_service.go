@@ -2,136 +2,601 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/logger"
 	"github.com/ruanv123/acme-hotel-api/internal/models"
 	"github.com/ruanv123/acme-hotel-api/internal/repository"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type contextKey string
 
 const (
-	UserContextKey contextKey = "user"
+	UserContextKey    contextKey = "user"
+	SessionContextKey contextKey = "session"
 )
 
+const (
+	// twoFactorChallengeTTL is how long a user has to enter the
+	// two-factor code after the password was accepted
+	twoFactorChallengeTTL = 5 * time.Minute
+)
+
+// twoFactorPurpose marks the short-lived JWTs proving the password step of
+// a two-factor login
+const twoFactorPurpose = "2fa"
+
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidToken       = errors.New("invalid token")
+	// ErrInvalidCredentials is the same for unknown e-mails and wrong
+	// passwords so the response doesn't reveal which e-mails have an account
+	ErrInvalidCredentials = apperrors.WithCode(apperrors.ErrInvalidCredentials, apperrors.CodeInvalidCredentials, "invalid email or password")
+	ErrInvalidToken       = apperrors.WithCode(apperrors.ErrUnauthenticated, "INVALID_TOKEN", "invalid token")
+	ErrTokenReused        = apperrors.WithCode(apperrors.ErrUnauthenticated, "TOKEN_REUSED", "refresh token reuse detected")
+	ErrAccountDisabled    = apperrors.WithCode(apperrors.ErrInsufficientPermission, "ACCOUNT_DISABLED", "account is disabled")
+	ErrRegistrationClosed = apperrors.WithCode(apperrors.ErrInsufficientPermission, "REGISTRATION_CLOSED", "registration requires an invitation")
+	ErrInvalidInvitation  = apperrors.WithCode(apperrors.ErrInvalidInput, "INVALID_INVITATION", "invalid or expired invitation")
+	ErrTwoFactorRequired  = errors.New("two-factor authentication code required")
 )
 
+// minPasswordLength is the shortest password accepted for an account
+const minPasswordLength = 8
+
+// maxPasswordLength is the longest password bcrypt can hash
+const maxPasswordLength = 72
+
+// RegisterInput carries the data of a new account. InvitationToken is
+// required when registration is invite-only.
+type RegisterInput struct {
+	Name            string
+	Email           string
+	Password        string
+	InvitationToken string
+}
+
+// UpdateUserInput holds profile changes; empty fields are left untouched
+type UpdateUserInput struct {
+	Name string
+	// CurrentPassword must match the stored one to set NewPassword
+	CurrentPassword string
+	NewPassword     string
+}
+
+// TwoFactorRequiredError is returned by Login when the password is right
+// but the account has two-factor authentication. ChallengeToken must be sent
+// to VerifyTwoFactor along with a code to complete the login.
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+	ExpiresAt      time.Time
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return ErrTwoFactorRequired.Error()
+}
+
+// Is lets errors.Is(err, ErrTwoFactorRequired) match a pending challenge.
+func (e *TwoFactorRequiredError) Is(target error) bool {
+	return target == ErrTwoFactorRequired
+}
+
+// AuthTokens is the pair of tokens issued at login and on every refresh
+type AuthTokens struct {
+	AccessToken string
+	// AccessTokenExpiresAt is when AccessToken stops being accepted
+	AccessTokenExpiresAt time.Time
+	RefreshToken         string
+}
+
 type AuthService interface {
-	Register(ctx context.Context, email, password, name string) (*models.User, error)
-	Login(ctx context.Context, email, password string) (token string, isAdmin bool, err error)
-	UpdateUser(ctx context.Context, userID uuid.UUID, name, password string) error
+	// Register creates an account, with the role of the invitation when
+	// input carries one
+	Register(ctx context.Context, input RegisterInput) (*models.User, error)
+	// Login checks the credentials of email. Repeated failures from the
+	// same account or clientIP make it return a *LoginThrottledError.
+	Login(ctx context.Context, email, password, clientIP string) (tokens *AuthTokens, isAdmin bool, err error)
+	// VerifyTwoFactor completes a login that returned a
+	// *TwoFactorRequiredError, with an authenticator or recovery code
+	VerifyTwoFactor(ctx context.Context, challengeToken, code, clientIP string) (tokens *AuthTokens, isAdmin bool, err error)
+	// Refresh exchanges a refresh token for a new token pair. Presenting a
+	// refresh token that was already used revokes the whole session.
+	Refresh(ctx context.Context, refreshToken string) (*AuthTokens, error)
+	Logout(ctx context.Context, refreshToken string) error
+	// UpdateUser changes the user's own profile. A password change ends
+	// every session of the user except sessionID.
+	UpdateUser(ctx context.Context, userID, sessionID uuid.UUID, input UpdateUserInput) (*models.User, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	// GrantAccess re-enables a disabled account
+	GrantAccess(ctx context.Context, userID uuid.UUID) error
+	// RevokeAccess disables an account and ends all of its sessions
+	RevokeAccess(ctx context.Context, actorID, userID uuid.UUID) error
+	// UnlockAccount clears the failed logins and lockout of an account
+	UnlockAccount(ctx context.Context, userID uuid.UUID) error
+	// PruneLoginFailures forgets the failed logins of unknown e-mails that
+	// can't count toward a lockout anymore
+	PruneLoginFailures(ctx context.Context) error
 
 	VerifyToken(token string) (*models.User, error)
-	VerifyTokenAdmin(token string) (*models.User, error)
+	// VerifySession is VerifyToken also returning the session the token
+	// was issued for
+	VerifySession(token string) (*models.User, uuid.UUID, error)
 }
 
 type authService struct {
-	userRepo  repository.UserRepository
-	jwtSecret string
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	invitationRepo   repository.InvitationRepository
+	loginFailureRepo repository.LoginFailureRepository
+	roleRepo         repository.RoleRepository
+	settings         AuthSettings
+	ipLimiter        *ipLimiter
+	secondFactor     secondFactor
+}
+
+// AuthSettings tunes how the auth service issues tokens and accepts
+// registrations
+type AuthSettings struct {
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// InviteOnly only lets accounts be registered through an invitation
+	InviteOnly bool
 }
 
 func NewAuthService(
 	userRepo repository.UserRepository,
-	jwtSecret string,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	invitationRepo repository.InvitationRepository,
+	recoveryCodeRepo repository.RecoveryCodeRepository,
+	loginFailureRepo repository.LoginFailureRepository,
+	roleRepo repository.RoleRepository,
+	settings AuthSettings,
 ) AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		invitationRepo:   invitationRepo,
+		loginFailureRepo: loginFailureRepo,
+		roleRepo:         roleRepo,
+		settings:         settings,
+		ipLimiter:        newIPLimiter(ipThrottle),
+		secondFactor:     secondFactor{userRepo: userRepo, recoveryCodeRepo: recoveryCodeRepo},
 	}
 }
 
-func (s *authService) Register(ctx context.Context, email string, password string, name string) (*models.User, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (s *authService) Register(ctx context.Context, input RegisterInput) (*models.User, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Email = normalizeEmail(input.Email)
+
+	if input.Name == "" {
+		return nil, apperrors.NewValidationError("name", "is required")
+	}
+	if len(input.Name) > 255 {
+		return nil, apperrors.NewValidationError("name", "must be at most 255 bytes")
+	}
+	if err := validateEmail("email", input.Email); err != nil {
+		return nil, err
+	}
+	if err := validatePassword("password", input.Password); err != nil {
+		return nil, err
+	}
+
+	var invitation *models.Invitation
+	if input.InvitationToken != "" {
+		var err error
+		invitation, err = s.invitationRepo.GetByHash(ctx, hashToken(input.InvitationToken))
+		if err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return nil, ErrInvalidInvitation
+			}
+			return nil, err
+		}
+		if !invitation.Pending(time.Now()) {
+			return nil, ErrInvalidInvitation
+		}
+		if invitation.Email != input.Email {
+			return nil, apperrors.NewValidationError("email", "does not match the invitation")
+		}
+	} else if s.settings.InviteOnly {
+		return nil, ErrRegistrationClosed
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &models.User{
 		ID:           uuid.New(),
-		Name:         name,
-		Email:        email,
+		Name:         input.Name,
+		Email:        input.Email,
 		PasswordHash: string(hashedPassword),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	if invitation == nil {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	user.Role = invitation.Role
+	if err := s.invitationRepo.Accept(ctx, invitation.ID, user); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil, ErrInvalidInvitation
+		}
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (s *authService) Login(ctx context.Context, email, password string) (string, bool, error) {
+func (s *authService) Login(ctx context.Context, email, password, clientIP string) (*AuthTokens, bool, error) {
+	now := time.Now()
+	if wait := s.ipLimiter.retryAfter(clientIP, now); wait > 0 {
+		return nil, false, &LoginThrottledError{RetryAfter: wait}
+	}
+
+	email = normalizeEmail(email)
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", false, err
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			return nil, false, err
+		}
+		return nil, false, s.unknownEmailLogin(ctx, email, password, clientIP, now)
+	}
+
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return nil, false, &LoginThrottledError{RetryAfter: user.LockedUntil.Sub(now)}
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", false, ErrInvalidCredentials
+		s.ipLimiter.recordFailure(clientIP, now)
+		if err := s.recordFailedLogin(ctx, user, now); err != nil {
+			return nil, false, err
+		}
+		return nil, false, ErrInvalidCredentials
 	}
 
-	isAdmin := user.Role == "admin"
+	if user.FailedLoginAttempts > 0 {
+		if err := s.userRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+			return nil, false, err
+		}
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"role":    user.Role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
+	if !user.Status {
+		return nil, false, ErrAccountDisabled
+	}
+
+	if user.TOTPEnabled {
+		return nil, false, s.twoFactorChallenge(ctx, user, now)
+	}
+
+	return s.startSession(ctx, user)
+}
+
+func (s *authService) VerifyTwoFactor(ctx context.Context, challengeToken, code, clientIP string) (*AuthTokens, bool, error) {
+	now := time.Now()
+	if wait := s.ipLimiter.retryAfter(clientIP, now); wait > 0 {
+		return nil, false, &LoginThrottledError{RetryAfter: wait}
+	}
+
+	userID, challengeID, err := s.parseTwoFactorChallenge(challengeToken)
+	if err != nil {
+		return nil, false, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, false, ErrInvalidToken
+		}
+		return nil, false, err
+	}
+	if !user.Status {
+		return nil, false, ErrAccountDisabled
+	}
+	if !user.TOTPEnabled {
+		return nil, false, ErrInvalidToken
+	}
+	// a used challenge, or one replaced by a newer login, is refused before
+	// a recovery code is spent on it
+	if user.TwoFactorChallengeID == nil || *user.TwoFactorChallengeID != challengeID {
+		return nil, false, ErrInvalidToken
+	}
+
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return nil, false, &LoginThrottledError{RetryAfter: user.LockedUntil.Sub(now)}
+	}
+
+	if err := s.secondFactor.check(ctx, user, code); err != nil {
+		if !errors.Is(err, ErrInvalidTwoFactorCode) {
+			return nil, false, err
+		}
+		// wrong codes count as failed logins so they can't be brute-forced
+		s.ipLimiter.recordFailure(clientIP, now)
+		if err := s.recordFailedLogin(ctx, user, now); err != nil {
+			return nil, false, err
+		}
+		return nil, false, ErrInvalidTwoFactorCode
+	}
 
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	// the challenge is only good for one login; a concurrent request may
+	// have used it since it was read
+	used, err := s.userRepo.UseTwoFactorChallenge(ctx, user.ID, challengeID)
 	if err != nil {
-		return "", false, err
+		return nil, false, err
+	}
+	if !used {
+		return nil, false, ErrInvalidToken
 	}
 
-	return tokenString, isAdmin, nil
+	if user.FailedLoginAttempts > 0 {
+		if err := s.userRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return s.startSession(ctx, user)
 }
 
-func (a *authService) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
-	user, err := a.userRepo.GetByID(ctx, userID)
+// startSession issues the tokens of a successful login. Every login starts
+// a new session, i.e. a new refresh token family. The user counts as an
+// admin when their role can manage users, whatever the role is called.
+func (s *authService) startSession(ctx context.Context, user *models.User) (*AuthTokens, bool, error) {
+	isAdmin, err := s.roleRepo.HasPermission(ctx, user.Role, models.PermissionUsersManage)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	return user, nil
+	refreshToken, stored, err := s.newRefreshToken(user.ID, uuid.New())
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.refreshTokenRepo.Create(ctx, stored); err != nil {
+		return nil, false, err
+	}
+
+	tokens, err := s.issueTokens(user, stored.FamilyID, refreshToken)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return tokens, isAdmin, nil
 }
 
-func (a *authService) UpdateUser(ctx context.Context, userID uuid.UUID, name string, password string) error {
-	panic("unimplemented")
+// twoFactorChallenge issues the token a password login hands out to an
+// account with two-factor authentication. Only the newest challenge of an
+// account is accepted, and only once.
+func (s *authService) twoFactorChallenge(ctx context.Context, user *models.User, now time.Time) error {
+	expiresAt := now.Add(twoFactorChallengeTTL)
+	challengeID := uuid.New()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"purpose": twoFactorPurpose,
+		"jti":     challengeID.String(),
+		"exp":     expiresAt.Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(s.settings.JWTSecret))
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.SetTwoFactorChallenge(ctx, user.ID, challengeID); err != nil {
+		return err
+	}
+
+	return &TwoFactorRequiredError{ChallengeToken: tokenString, ExpiresAt: expiresAt}
 }
 
-func (a *authService) VerifyToken(tokenString string) (*models.User, error) {
+// parseTwoFactorChallenge returns the user and the challenge ID of a
+// challenge token
+func (s *authService) parseTwoFactorChallenge(tokenString string) (uuid.UUID, uuid.UUID, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(a.jwtSecret), nil
+		return []byte(s.settings.JWTSecret), nil
 	})
-
 	if err != nil || !token.Valid {
-		return nil, err
+		return uuid.Nil, uuid.Nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, ErrInvalidToken
+		return uuid.Nil, uuid.Nil, ErrInvalidToken
+	}
+
+	if purpose, _ := claims["purpose"].(string); purpose != twoFactorPurpose {
+		return uuid.Nil, uuid.Nil, ErrInvalidToken
 	}
 
-	userID, err := uuid.Parse(claims["user_id"].(string))
+	userIDClaim, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDClaim)
 	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidToken
+	}
+
+	challengeIDClaim, _ := claims["jti"].(string)
+	challengeID, err := uuid.Parse(challengeIDClaim)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidToken
+	}
+
+	return userID, challengeID, nil
+}
+
+// unknownEmailLogin fails a login for an e-mail that belongs to no account
+// the same way a wrong password fails for a real one: it takes as long,
+// and the e-mail gets locked after as many attempts
+func (s *authService) unknownEmailLogin(ctx context.Context, email, password, clientIP string, now time.Time) error {
+	failure, err := s.loginFailureRepo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return err
+	}
+	if failure != nil && failure.LockedUntil != nil && now.Before(*failure.LockedUntil) {
+		return &LoginThrottledError{RetryAfter: failure.LockedUntil.Sub(now)}
+	}
+
+	bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+	s.ipLimiter.recordFailure(clientIP, now)
+
+	failures, err := s.loginFailureRepo.Record(ctx, email, now.Add(-accountThrottle.window))
+	if err != nil {
+		return err
+	}
+	if delay := accountThrottle.delay(failures); delay > 0 {
+		if err := s.loginFailureRepo.LockUntil(ctx, email, now.Add(delay)); err != nil {
+			return err
+		}
+	}
+
+	return ErrInvalidCredentials
+}
+
+// recordFailedLogin counts a wrong password for user and locks the account
+// once the failures go past the free attempts
+func (s *authService) recordFailedLogin(ctx context.Context, user *models.User, now time.Time) error {
+	failures, err := s.userRepo.RecordFailedLogin(ctx, user.ID, now.Add(-accountThrottle.window))
+	if err != nil {
+		return err
+	}
+
+	delay := accountThrottle.delay(failures)
+	if delay == 0 {
+		return nil
+	}
+
+	if failures >= accountThrottle.lockoutAfter {
+		logger.LogEvent(logrus.WarnLevel, "Account locked after failed logins", logrus.Fields{
+			"user_id":  user.ID.String(),
+			"failures": failures,
+		})
+	}
+
+	return s.userRepo.LockUntil(ctx, user.ID, now.Add(delay))
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash is compared against when the e-mail is unknown
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*AuthTokens, error) {
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+	if time.Now().After(stored.ExpiresAt) {
 		return nil, ErrInvalidToken
 	}
 
-	user, err := a.userRepo.GetByID(context.Background(), userID)
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Status {
+		return nil, ErrAccountDisabled
+	}
+
+	newToken, replacement, err := s.newRefreshToken(user.ID, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.Rotate(ctx, stored, replacement); err != nil {
+		// another request rotated the same token first
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, err
+	}
+
+	return s.issueTokens(user, stored.FamilyID, newToken)
+}
+
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// revokeReusedFamily ends the session of a refresh token presented after it
+// was rotated, as either the legitimate user or an attacker holds a copy
+func (s *authService) revokeReusedFamily(ctx context.Context, stored *models.RefreshToken) error {
+	logger.LogEvent(logrus.WarnLevel, "Refresh token reuse detected", logrus.Fields{
+		"user_id":   stored.UserID.String(),
+		"family_id": stored.FamilyID.String(),
+	})
+
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		return err
+	}
+
+	return ErrTokenReused
+}
+
+func (s *authService) issueTokens(user *models.User, familyID uuid.UUID, refreshToken string) (*AuthTokens, error) {
+	expiresAt := time.Now().Add(s.settings.AccessTokenTTL)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"role":    user.Role,
+		"sid":     familyID.String(),
+		"exp":     expiresAt.Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(s.settings.JWTSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthTokens{
+		AccessToken:          tokenString,
+		AccessTokenExpiresAt: expiresAt,
+		RefreshToken:         refreshToken,
+	}, nil
+}
+
+func (a *authService) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := a.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -139,42 +604,157 @@ func (a *authService) VerifyToken(tokenString string) (*models.User, error) {
 	return user, nil
 }
 
-var (
-	ErrUnauthorized = errors.New("user is not authorized as admin")
-)
+func (a *authService) GrantAccess(ctx context.Context, userID uuid.UUID) error {
+	return a.userRepo.GrantAccess(ctx, userID)
+}
+
+func (a *authService) RevokeAccess(ctx context.Context, actorID, userID uuid.UUID) error {
+	if actorID == userID {
+		return apperrors.NewValidationError("id", "you cannot disable your own account")
+	}
+
+	if err := a.userRepo.RevokeAccess(ctx, userID); err != nil {
+		return lastUserManager(err)
+	}
+
+	// access tokens are checked against the user status on every request,
+	// revoking the refresh tokens also stops them from being renewed
+	return a.refreshTokenRepo.RevokeAllForUser(ctx, userID)
+}
+
+func (a *authService) UnlockAccount(ctx context.Context, userID uuid.UUID) error {
+	return a.userRepo.ResetFailedLogins(ctx, userID)
+}
+
+func (a *authService) PruneLoginFailures(ctx context.Context) error {
+	deleted, err := a.loginFailureRepo.DeleteStale(ctx, time.Now().Add(-accountThrottle.window))
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		logger.LogEvent(logrus.InfoLevel, "Pruned stale login failures", logrus.Fields{
+			"deleted": deleted,
+		})
+	}
+
+	return nil
+}
+
+func (a *authService) UpdateUser(ctx context.Context, userID, sessionID uuid.UUID, input UpdateUserInput) (*models.User, error) {
+	user, err := a.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &models.User{ID: user.ID}
 
-func (s *authService) VerifyTokenAdmin(tokenString string) (*models.User, error) {
+	if name := strings.TrimSpace(input.Name); name != "" {
+		if len(name) > 255 {
+			return nil, apperrors.NewValidationError("name", "must be at most 255 bytes")
+		}
+		changes.Name = name
+	}
+
+	if input.NewPassword != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)); err != nil {
+			return nil, apperrors.NewValidationError("current_password", "does not match your password")
+		}
+		if err := validatePassword("password", input.NewPassword); err != nil {
+			return nil, err
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		changes.PasswordHash = string(hashedPassword)
+	}
+
+	if changes.Name == "" && changes.PasswordHash == "" {
+		return user, nil
+	}
+
+	if err := a.userRepo.Update(ctx, changes); err != nil {
+		return nil, err
+	}
+
+	if changes.PasswordHash != "" {
+		// whoever else holds a session may have known the old password
+		if err := a.refreshTokenRepo.RevokeAllForUserExcept(ctx, user.ID, sessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.userRepo.GetByID(ctx, user.ID)
+}
+
+func (a *authService) VerifyToken(tokenString string) (*models.User, error) {
+	user, _, err := a.verify(tokenString)
+	return user, err
+}
+
+func (a *authService) VerifySession(tokenString string) (*models.User, uuid.UUID, error) {
+	return a.verify(tokenString)
+}
+
+// verify checks the access token signature and expiry, and that the
+// session it belongs to has not been revoked
+func (s *authService) verify(tokenString string) (*models.User, uuid.UUID, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(s.jwtSecret), nil
+		return []byte(s.settings.JWTSecret), nil
 	})
 
 	if err != nil || !token.Valid {
-		return nil, ErrInvalidToken
+		return nil, uuid.Nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, ErrInvalidToken
+		return nil, uuid.Nil, ErrInvalidToken
 	}
 
-	userID, err := uuid.Parse(claims["user_id"].(string))
+	// two-factor challenges are signed with the same secret but don't
+	// grant access
+	if _, ok := claims["purpose"]; ok {
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+
+	userIDClaim, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDClaim)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, uuid.Nil, ErrInvalidToken
 	}
 
-	user, err := s.userRepo.GetByID(context.Background(), userID)
+	sidClaim, _ := claims["sid"].(string)
+	familyID, err := uuid.Parse(sidClaim)
 	if err != nil {
-		return nil, err
+		return nil, uuid.Nil, ErrInvalidToken
 	}
 
-	if user.Role != "admin" {
-		return nil, ErrUnauthorized
+	ctx := context.Background()
+
+	active, err := s.refreshTokenRepo.IsFamilyActive(ctx, familyID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if !active {
+		return nil, uuid.Nil, ErrInvalidToken
 	}
 
-	return user, nil
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	if !user.Status {
+		return nil, uuid.Nil, ErrAccountDisabled
+	}
+
+	return user, familyID, nil
 }
 
 // hellper functions
@@ -186,3 +766,82 @@ func UserFromContext(ctx context.Context) (*models.User, bool) {
 	user, ok := ctx.Value(UserContextKey).(*models.User)
 	return user, ok
 }
+
+func WithSessionContext(ctx context.Context, sessionID uuid.UUID) context.Context {
+	return context.WithValue(ctx, SessionContextKey, sessionID)
+}
+
+func SessionFromContext(ctx context.Context) (uuid.UUID, bool) {
+	sessionID, ok := ctx.Value(SessionContextKey).(uuid.UUID)
+	return sessionID, ok
+}
+
+// newRefreshToken returns a random refresh token and the record storing its
+// hash; the plain token is only ever handed to the client
+func (s *authService) newRefreshToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.settings.RefreshTokenTTL),
+	}, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validateEmail(field, email string) error {
+	if email == "" {
+		return apperrors.NewValidationError(field, "is required")
+	}
+	if _, err := mail.ParseAddress(email); err != nil || len(email) > 255 {
+		return apperrors.NewValidationError(field, "must be a valid email address")
+	}
+	return nil
+}
+
+// validatePassword enforces the password policy: 8 to 72 bytes, the most
+// bcrypt reads, with at least one letter and one digit
+func validatePassword(field, password string) error {
+	if len(password) < minPasswordLength {
+		return apperrors.NewValidationError(field, fmt.Sprintf("must be at least %d bytes", minPasswordLength))
+	}
+	if len(password) > maxPasswordLength {
+		return apperrors.NewValidationError(field, fmt.Sprintf("must be at most %d bytes", maxPasswordLength))
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return apperrors.NewValidationError(field, "must contain at least one letter and one digit")
+	}
+
+	return nil
+}
+
+// randomToken returns 32 random bytes encoded for use in URLs
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+	"github.com/ruanv123/acme-hotel-api/internal/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestAuthService(users *fakeUserRepo, tokens *fakeRefreshTokenRepo) *authService {
+	return &authService{
+		userRepo:         users,
+		refreshTokenRepo: tokens,
+		roleRepo:         newFakeRoleRepo(),
+		settings: AuthSettings{
+			JWTSecret:       "test-secret",
+			AccessTokenTTL:  time.Minute,
+			RefreshTokenTTL: time.Hour,
+		},
+		ipLimiter:    newIPLimiter(ipThrottle),
+		secondFactor: secondFactor{userRepo: users, recoveryCodeRepo: newFakeRecoveryCodeRepo()},
+	}
+}
+
+// newTwoFactorUser adds an account with two-factor authentication and
+// returns it with a function giving its TOTP code offset steps from now
+func newTwoFactorUser(t *testing.T, users *fakeUserRepo) (*models.User, func(offset int64) string) {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("right-password-1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{
+		ID:           uuid.New(),
+		Email:        "ana@example.com",
+		PasswordHash: string(hash),
+		Status:       true,
+		TOTPSecret:   secret,
+		TOTPEnabled:  true,
+	}
+	users.add(user)
+
+	code := func(offset int64) string {
+		code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	return user, code
+}
+
+// passwordStep logs in with the right password and returns the two-factor
+// challenge token
+func passwordStep(t *testing.T, auth *authService) string {
+	t.Helper()
+
+	_, _, err := auth.Login(context.Background(), "ana@example.com", "right-password-1", "10.0.0.1")
+	var challenge *TwoFactorRequiredError
+	if !errors.As(err, &challenge) {
+		t.Fatalf("Login returned %v; want a two-factor challenge", err)
+	}
+	return challenge.ChallengeToken
+}
+
+func TestVerifyTwoFactorChallengeIsSingleUse(t *testing.T) {
+	t.Run("replaying a used challenge", func(t *testing.T) {
+		users := newFakeUserRepo()
+		_, code := newTwoFactorUser(t, users)
+		auth := newTestAuthService(users, newFakeRefreshTokenRepo())
+
+		challenge := passwordStep(t, auth)
+		if _, _, err := auth.VerifyTwoFactor(context.Background(), challenge, code(0), "10.0.0.1"); err != nil {
+			t.Fatalf("VerifyTwoFactor returned %v", err)
+		}
+		// even with a fresh code, the challenge itself was used up
+		if _, _, err := auth.VerifyTwoFactor(context.Background(), challenge, code(1), "10.0.0.1"); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("replayed VerifyTwoFactor returned %v; want %v", err, ErrInvalidToken)
+		}
+	})
+
+	t.Run("a newer login replaces the challenge", func(t *testing.T) {
+		users := newFakeUserRepo()
+		_, code := newTwoFactorUser(t, users)
+		auth := newTestAuthService(users, newFakeRefreshTokenRepo())
+
+		first := passwordStep(t, auth)
+		second := passwordStep(t, auth)
+		if _, _, err := auth.VerifyTwoFactor(context.Background(), first, code(0), "10.0.0.1"); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("VerifyTwoFactor with the replaced challenge returned %v; want %v", err, ErrInvalidToken)
+		}
+		if _, _, err := auth.VerifyTwoFactor(context.Background(), second, code(0), "10.0.0.1"); err != nil {
+			t.Fatalf("VerifyTwoFactor with the newest challenge returned %v", err)
+		}
+	})
+}
+
+func TestVerifyTwoFactorCountsWrongCodes(t *testing.T) {
+	users := newFakeUserRepo()
+	user, code := newTwoFactorUser(t, users)
+	auth := newTestAuthService(users, newFakeRefreshTokenRepo())
+	challenge := passwordStep(t, auth)
+
+	// a code long expired is wrong without being a guess that could match
+	wrong := code(-10)
+	for i := 1; i <= accountThrottle.freeAttempts+1; i++ {
+		if _, _, err := auth.VerifyTwoFactor(context.Background(), challenge, wrong, "10.0.0.1"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("attempt %d: VerifyTwoFactor returned %v; want %v", i, err, ErrInvalidTwoFactorCode)
+		}
+		if got := users.users[user.ID].FailedLoginAttempts; got != i {
+			t.Fatalf("attempt %d: failed login attempts = %d; want %d", i, got, i)
+		}
+	}
+
+	// past the free attempts the account is locked, right code or not
+	_, _, err := auth.VerifyTwoFactor(context.Background(), challenge, code(0), "10.0.0.1")
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("VerifyTwoFactor on a locked account returned %v; want a *LoginThrottledError", err)
+	}
+	if attempts := auth.ipLimiter.attempts["10.0.0.1"]; attempts == nil || attempts.failures != accountThrottle.freeAttempts+1 {
+		t.Errorf("IP attempts = %+v; want %d failures", attempts, accountThrottle.freeAttempts+1)
+	}
+}
+
+func TestStartSessionAdmin(t *testing.T) {
+	roles := newFakeRoleRepo()
+	roles.add("manager", models.PermissionUsersManage)
+	roles.add("admin", models.PermissionRoomsRead)
+
+	tests := []struct {
+		role      string
+		wantAdmin bool
+	}{
+		{role: "manager", wantAdmin: true},
+		{role: "admin", wantAdmin: false},
+		{role: "user", wantAdmin: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			users := newFakeUserRepo()
+			user := &models.User{ID: uuid.New(), Role: tt.role, Status: true}
+			users.add(user)
+			auth := newTestAuthService(users, newFakeRefreshTokenRepo())
+			auth.roleRepo = roles
+
+			_, isAdmin, err := auth.startSession(context.Background(), user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isAdmin != tt.wantAdmin {
+				t.Errorf("admin = %v; want %v", isAdmin, tt.wantAdmin)
+			}
+		})
+	}
+}
+
+func TestRefreshDetectsReuse(t *testing.T) {
+	// each step refreshes one of the tokens handed out so far; "first" is
+	// the login token and "second" the one its refresh returned
+	tests := []struct {
+		name    string
+		steps   []string
+		wantErr []error
+	}{
+		{
+			name:    "rotating each token once",
+			steps:   []string{"first", "second"},
+			wantErr: []error{nil, nil},
+		},
+		{
+			name:    "replaying a rotated token",
+			steps:   []string{"first", "first"},
+			wantErr: []error{nil, ErrTokenReused},
+		},
+		{
+			name:    "reuse ends the session for the legitimate holder too",
+			steps:   []string{"first", "first", "second"},
+			wantErr: []error{nil, ErrTokenReused, ErrTokenReused},
+		},
+		{
+			name:    "the thief replaying after the owner refreshed",
+			steps:   []string{"first", "second", "first"},
+			wantErr: []error{nil, nil, ErrTokenReused},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUserRepo()
+			user := &models.User{ID: uuid.New(), Status: true}
+			users.add(user)
+			tokens := newFakeRefreshTokenRepo()
+			auth := newTestAuthService(users, tokens)
+
+			login, _, err := auth.startSession(context.Background(), user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			handedOut := map[string]string{"first": login.RefreshToken}
+
+			for i, step := range tt.steps {
+				refreshed, err := auth.Refresh(context.Background(), handedOut[step])
+				if !errors.Is(err, tt.wantErr[i]) {
+					t.Fatalf("step %d (%s): Refresh returned %v; want %v", i, step, err, tt.wantErr[i])
+				}
+				if err == nil && handedOut["second"] == "" {
+					handedOut["second"] = refreshed.RefreshToken
+				}
+			}
+
+			if tt.wantErr[len(tt.wantErr)-1] == ErrTokenReused {
+				familyActive := false
+				for _, token := range tokens.tokens {
+					if token.RevokedAt == nil {
+						familyActive = true
+					}
+				}
+				if familyActive {
+					t.Error("a token of the reused family is still active")
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		prepare func(token *models.RefreshToken, user *models.User)
+		token   string
+		wantErr error
+	}{
+		{"unknown token", nil, "not-a-token", ErrInvalidToken},
+		{"expired token", func(token *models.RefreshToken, user *models.User) { token.ExpiresAt = expired }, "", ErrInvalidToken},
+		{"disabled account", func(token *models.RefreshToken, user *models.User) { user.Status = false }, "", ErrAccountDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUserRepo()
+			user := &models.User{ID: uuid.New(), Status: true}
+			users.add(user)
+			tokens := newFakeRefreshTokenRepo()
+			auth := newTestAuthService(users, tokens)
+
+			login, _, err := auth.startSession(context.Background(), user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.prepare != nil {
+				tt.prepare(tokens.tokens[hashToken(login.RefreshToken)], user)
+			}
+
+			token := tt.token
+			if token == "" {
+				token = login.RefreshToken
+			}
+			if _, err := auth.Refresh(context.Background(), token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Refresh returned %v; want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRefreshRacingRotationIsReuse(t *testing.T) {
+	users := newFakeUserRepo()
+	user := &models.User{ID: uuid.New(), Status: true}
+	users.add(user)
+	tokens := newFakeRefreshTokenRepo()
+	auth := newTestAuthService(users, tokens)
+
+	login, _, err := auth.startSession(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another request rotates the same token between our read and our write
+	tokens.beforeRotate = func() {
+		tokens.beforeRotate = nil
+		if _, err := auth.Refresh(context.Background(), login.RefreshToken); err != nil {
+			t.Errorf("the racing refresh returned %v", err)
+		}
+	}
+
+	if _, err := auth.Refresh(context.Background(), login.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("Refresh returned %v; want %v", err, ErrTokenReused)
+	}
+
+	active, err := tokens.IsFamilyActive(context.Background(), tokens.tokens[hashToken(login.RefreshToken)].FamilyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active {
+		t.Error("the session survived a racing reuse")
+	}
+}
+
+// The fakes below keep the auth service's state in memory and only
+// implement the methods it reaches; the embedded interface panics on any
+// other call.
+
+// fakeUserRepo keeps the accounts the auth service reads and the failed
+// login counters it updates
+type fakeUserRepo struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[uuid.UUID]*models.User
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{users: make(map[uuid.UUID]*models.User)}
+}
+
+func (r *fakeUserRepo) add(user *models.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[user.ID] = user
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *fakeUserRepo) RecordFailedLogin(ctx context.Context, id uuid.UUID, resetBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return 0, apperrors.ErrNotFound
+	}
+	if user.LastFailedLoginAt == nil || user.LastFailedLoginAt.Before(resetBefore) {
+		user.FailedLoginAttempts = 0
+	}
+	now := time.Now()
+	user.FailedLoginAttempts++
+	user.LastFailedLoginAt = &now
+	return user.FailedLoginAttempts, nil
+}
+
+func (r *fakeUserRepo) LockUntil(ctx context.Context, id uuid.UUID, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	user.LockedUntil = &until
+	return nil
+}
+
+func (r *fakeUserRepo) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return false, apperrors.ErrNotFound
+	}
+	if user.TOTPLastStep != nil && step <= *user.TOTPLastStep {
+		return false, nil
+	}
+	user.TOTPLastStep = &step
+	return true, nil
+}
+
+func (r *fakeUserRepo) SetTwoFactorChallenge(ctx context.Context, id, challengeID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	user.TwoFactorChallengeID = &challengeID
+	return nil
+}
+
+func (r *fakeUserRepo) UseTwoFactorChallenge(ctx context.Context, id, challengeID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TwoFactorChallengeID == nil || *user.TwoFactorChallengeID != challengeID {
+		return false, nil
+	}
+	user.TwoFactorChallengeID = nil
+	return true, nil
+}
+
+func (r *fakeUserRepo) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+	return nil
+}
+
+// fakeRefreshTokenRepo stores refresh tokens by hash
+type fakeRefreshTokenRepo struct {
+	repository.RefreshTokenRepository
+
+	mu     sync.Mutex
+	tokens map[string]*models.RefreshToken
+	// beforeRotate runs inside Rotate, to play a request racing this one
+	beforeRotate func()
+}
+
+func newFakeRefreshTokenRepo() *fakeRefreshTokenRepo {
+	return &fakeRefreshTokenRepo{tokens: make(map[string]*models.RefreshToken)}
+}
+
+func (r *fakeRefreshTokenRepo) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+	stored := *token
+	r.tokens[token.TokenHash] = &stored
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[hash]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	found := *token
+	return &found, nil
+}
+
+func (r *fakeRefreshTokenRepo) Rotate(ctx context.Context, old *models.RefreshToken, replacement *models.RefreshToken) error {
+	if r.beforeRotate != nil {
+		r.beforeRotate()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tokens[old.TokenHash]
+	if !ok || stored.RevokedAt != nil {
+		return apperrors.ErrConflict
+	}
+
+	if replacement.ID == uuid.Nil {
+		replacement.ID = uuid.New()
+	}
+	now := time.Now()
+	stored.RevokedAt = &now
+	stored.ReplacedByID = &replacement.ID
+
+	saved := *replacement
+	r.tokens[replacement.TokenHash] = &saved
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil && time.Now().Before(token.ExpiresAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
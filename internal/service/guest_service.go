@@ -1 +1,160 @@
 package service
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+	"github.com/ruanv123/acme-hotel-api/internal/validation"
+)
+
+// GuestInput carries the editable fields of a guest
+type GuestInput struct {
+	Name        string
+	Cpf         string
+	DataNasc    time.Time
+	Telefone    string
+	Email       string
+	Observacoes string
+}
+
+type GuestService interface {
+	Create(ctx context.Context, input GuestInput) (*models.Guest, error)
+	List(ctx context.Context) ([]models.Guest, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Guest, error)
+	Update(ctx context.Context, id uuid.UUID, input GuestInput) (*models.Guest, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type guestService struct {
+	guestRepo repository.GuestRepository
+}
+
+func NewGuestService(guestRepo repository.GuestRepository) GuestService {
+	return &guestService{
+		guestRepo: guestRepo,
+	}
+}
+
+func (s *guestService) Create(ctx context.Context, input GuestInput) (*models.Guest, error) {
+	input, err := normalizeGuestInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	guest := &models.Guest{
+		Name:        input.Name,
+		Cpf:         input.Cpf,
+		DataNasc:    input.DataNasc,
+		Telefone:    input.Telefone,
+		Email:       input.Email,
+		Observacoes: input.Observacoes,
+	}
+
+	if err := s.guestRepo.Create(ctx, guest); err != nil {
+		return nil, err
+	}
+
+	return guest, nil
+}
+
+func (s *guestService) List(ctx context.Context) ([]models.Guest, error) {
+	return s.guestRepo.ListAll(ctx)
+}
+
+func (s *guestService) GetByID(ctx context.Context, id uuid.UUID) (*models.Guest, error) {
+	return s.guestRepo.GetByID(ctx, id)
+}
+
+func (s *guestService) Update(ctx context.Context, id uuid.UUID, input GuestInput) (*models.Guest, error) {
+	input, err := normalizeGuestInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	guest, err := s.guestRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	guest.Name = input.Name
+	guest.Cpf = input.Cpf
+	guest.DataNasc = input.DataNasc
+	guest.Telefone = input.Telefone
+	guest.Email = input.Email
+	guest.Observacoes = input.Observacoes
+
+	if err := s.guestRepo.Update(ctx, guest); err != nil {
+		return nil, err
+	}
+
+	return guest, nil
+}
+
+func (s *guestService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.guestRepo.Delete(ctx, id)
+}
+
+// normalizeGuestInput trims the input, validates it and stores the CPF in
+// its canonical 11 digit form so formatted and unformatted values collide
+// on the unique index
+func normalizeGuestInput(input GuestInput) (GuestInput, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Cpf = strings.TrimSpace(input.Cpf)
+	input.Telefone = strings.TrimSpace(input.Telefone)
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	input.Observacoes = strings.TrimSpace(input.Observacoes)
+
+	if err := validateGuestInput(input); err != nil {
+		return input, err
+	}
+
+	cpf, ok := validation.NormalizeCPF(input.Cpf)
+	if !ok {
+		return input, errors.NewValidationError("cpf", "is not a valid CPF")
+	}
+	input.Cpf = cpf
+
+	return input, nil
+}
+
+func validateGuestInput(input GuestInput) error {
+	if input.Name == "" {
+		return errors.NewValidationError("name", "is required")
+	}
+	if len(input.Name) > 255 {
+		return errors.NewValidationError("name", "must be at most 255 bytes")
+	}
+
+	if input.Cpf == "" {
+		return errors.NewValidationError("cpf", "is required")
+	}
+
+	if input.DataNasc.IsZero() {
+		return errors.NewValidationError("data_nasc", "is required")
+	}
+	if input.DataNasc.After(time.Now()) {
+		return errors.NewValidationError("data_nasc", "must be in the past")
+	}
+
+	if input.Telefone == "" {
+		return errors.NewValidationError("telefone", "is required")
+	}
+	if len(input.Telefone) > 15 {
+		return errors.NewValidationError("telefone", "must be at most 15 bytes")
+	}
+
+	if input.Email == "" {
+		return errors.NewValidationError("email", "is required")
+	}
+	if _, err := mail.ParseAddress(input.Email); err != nil || len(input.Email) > 255 {
+		return errors.NewValidationError("email", "must be a valid email address")
+	}
+
+	return nil
+}
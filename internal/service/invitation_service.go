@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/background"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/logger"
+	"github.com/ruanv123/acme-hotel-api/internal/mailer"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var ErrInvitationNotPending = fmt.Errorf("%w: invitation was already accepted or revoked", errors.ErrConflict)
+
+// InvitationInput carries the invitation an admin sends to a new member of
+// the staff
+type InvitationInput struct {
+	Email string
+	Role  string
+}
+
+type InvitationService interface {
+	// Create invites email to register with role and e-mails the link,
+	// which is also returned for the admin to share
+	Create(ctx context.Context, actorID uuid.UUID, input InvitationInput) (*models.Invitation, string, error)
+	List(ctx context.Context) ([]models.Invitation, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+}
+
+type invitationService struct {
+	invitationRepo repository.InvitationRepository
+	userRepo       repository.UserRepository
+	roleRepo       repository.RoleRepository
+	mailer         mailer.Mailer
+	tasks          *background.Group
+	invitationURL  string
+}
+
+// NewInvitationService builds the invitation links by appending the token
+// as the "token" query parameter of invitationURL
+func NewInvitationService(
+	invitationRepo repository.InvitationRepository,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	mailer mailer.Mailer,
+	tasks *background.Group,
+	invitationURL string,
+) InvitationService {
+	return &invitationService{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		mailer:         mailer,
+		tasks:          tasks,
+		invitationURL:  invitationURL,
+	}
+}
+
+func (s *invitationService) Create(ctx context.Context, actorID uuid.UUID, input InvitationInput) (*models.Invitation, string, error) {
+	input.Email = normalizeEmail(input.Email)
+	input.Role = normalizeRoleName(input.Role)
+
+	if err := validateEmail("email", input.Email); err != nil {
+		return nil, "", err
+	}
+	if input.Role == "" {
+		return nil, "", errors.NewValidationError("role", "is required")
+	}
+
+	if _, err := s.roleRepo.GetByName(ctx, input.Role); err != nil {
+		if stderrors.Is(err, errors.ErrNotFound) {
+			return nil, "", errors.NewValidationError("role", "does not exist")
+		}
+		return nil, "", err
+	}
+
+	_, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err == nil {
+		return nil, "", errors.NewValidationError("email", "already has an account")
+	}
+	if !stderrors.Is(err, errors.ErrNotFound) {
+		return nil, "", err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	link, err := tokenLink(s.invitationURL, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	invitation := &models.Invitation{
+		Email:       input.Email,
+		Role:        input.Role,
+		TokenHash:   hashToken(token),
+		InvitedByID: &actorID,
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, "", err
+	}
+
+	msg := mailer.Message{
+		To:      invitation.Email,
+		Subject: "You have been invited to Acme Hotel",
+		Body: fmt.Sprintf("Hello,\n\n"+
+			"You have been invited to join the Acme Hotel staff as %s. Create your account within %d days:\n\n"+
+			"%s\n",
+			invitation.Role, int(invitationTTL.Hours()/24), link),
+	}
+
+	s.tasks.Go(30*time.Second, func(ctx context.Context) {
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			logger.LogEvent(logrus.ErrorLevel, "Failed to send invitation e-mail", logrus.Fields{
+				"invitation_id": invitation.ID.String(),
+				"error":         err.Error(),
+			})
+		}
+	})
+
+	return invitation, link, nil
+}
+
+func (s *invitationService) List(ctx context.Context) ([]models.Invitation, error) {
+	return s.invitationRepo.ListAll(ctx)
+}
+
+func (s *invitationService) Revoke(ctx context.Context, id uuid.UUID) error {
+	err := s.invitationRepo.Revoke(ctx, id)
+	if stderrors.Is(err, errors.ErrConflict) {
+		return ErrInvitationNotPending
+	}
+	return err
+}
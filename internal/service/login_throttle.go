@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+)
+
+var ErrTooManyLoginAttempts = apperrors.WithCode(apperrors.ErrTooManyRequests, "TOO_MANY_LOGIN_ATTEMPTS", "too many failed login attempts")
+
+// LoginThrottledError is returned by Login while an account or client IP is
+// blocked after repeated failures
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s, try again in %s", ErrTooManyLoginAttempts, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap lets errors.Is(err, ErrTooManyLoginAttempts) match a throttled
+// login.
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// throttlePolicy blocks a login key for a delay doubling with every failure
+// past the free ones, and for the full lockout once lockoutAfter is reached.
+// A failure more than window after the previous one starts the count over,
+// so a lockout isn't renewed forever by one attempt after each.
+type throttlePolicy struct {
+	freeAttempts    int
+	lockoutAfter    int
+	lockoutDuration time.Duration
+	window          time.Duration
+}
+
+var (
+	accountThrottle = throttlePolicy{
+		freeAttempts:    3,
+		lockoutAfter:    10,
+		lockoutDuration: 15 * time.Minute,
+		window:          15 * time.Minute,
+	}
+	// many users may share an IP behind a NAT, so it gets a larger budget
+	ipThrottle = throttlePolicy{
+		freeAttempts:    10,
+		lockoutAfter:    50,
+		lockoutDuration: 15 * time.Minute,
+		window:          15 * time.Minute,
+	}
+)
+
+// delay returns how long to block after the given number of failures
+func (p throttlePolicy) delay(failures int) time.Duration {
+	if failures >= p.lockoutAfter {
+		return p.lockoutDuration
+	}
+	if failures <= p.freeAttempts {
+		return 0
+	}
+
+	// doubling step by step stops at the lockout instead of overflowing
+	// when lockoutAfter is large
+	delay := time.Second
+	for n := p.freeAttempts + 1; n < failures && delay < p.lockoutDuration; n++ {
+		delay *= 2
+	}
+	if delay > p.lockoutDuration {
+		delay = p.lockoutDuration
+	}
+	return delay
+}
+
+type ipAttempts struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// ipLimiter counts the failed logins of every client IP in memory. A
+// counter is forgotten once the IP has gone the policy window without failing.
+type ipLimiter struct {
+	mu        sync.Mutex
+	policy    throttlePolicy
+	attempts  map[string]*ipAttempts
+	lastSweep time.Time
+}
+
+func newIPLimiter(policy throttlePolicy) *ipLimiter {
+	return &ipLimiter{
+		policy:   policy,
+		attempts: make(map[string]*ipAttempts),
+	}
+}
+
+// retryAfter returns how long ip is still blocked for, or 0
+func (l *ipLimiter) retryAfter(ip string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.attempts[ip]
+	if !ok || !now.Before(entry.blockedUntil) {
+		return 0
+	}
+	return entry.blockedUntil.Sub(now)
+}
+
+func (l *ipLimiter) recordFailure(ip string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	entry, ok := l.attempts[ip]
+	if !ok || now.Sub(entry.lastFailure) > l.policy.window {
+		entry = &ipAttempts{}
+		l.attempts[ip] = entry
+	}
+
+	entry.failures++
+	entry.lastFailure = now
+	if delay := l.policy.delay(entry.failures); delay > 0 {
+		entry.blockedUntil = now.Add(delay)
+	}
+}
+
+// sweep drops the expired counters, at most once a minute
+func (l *ipLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for ip, entry := range l.attempts {
+		if now.Sub(entry.lastFailure) > l.policy.window && !now.Before(entry.blockedUntil) {
+			delete(l.attempts, ip)
+		}
+	}
+}
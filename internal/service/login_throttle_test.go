@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestThrottlePolicyDelay(t *testing.T) {
+	policy := throttlePolicy{freeAttempts: 3, lockoutAfter: 10, lockoutDuration: 15 * time.Minute}
+	// a policy whose doubling would pass the lockout long before lockoutAfter,
+	// and whose shift would overflow int64 if it weren't capped
+	long := throttlePolicy{freeAttempts: 0, lockoutAfter: 1000, lockoutDuration: time.Hour}
+
+	tests := []struct {
+		name     string
+		policy   throttlePolicy
+		failures int
+		want     time.Duration
+	}{
+		{"no failures", policy, 0, 0},
+		{"last free attempt", policy, 3, 0},
+		{"first delayed failure", policy, 4, time.Second},
+		{"doubles", policy, 5, 2 * time.Second},
+		{"keeps doubling", policy, 9, 32 * time.Second},
+		{"lockout", policy, 10, 15 * time.Minute},
+		{"past lockout", policy, 50, 15 * time.Minute},
+		{"capped below lockoutAfter", long, 13, time.Hour},
+		{"no overflow at 64 doublings", long, 65, time.Hour},
+		{"no overflow far past 64 doublings", long, 999, time.Hour},
+		{"account policy lockout", accountThrottle, accountThrottle.lockoutAfter, accountThrottle.lockoutDuration},
+		{"ip policy free attempts", ipThrottle, ipThrottle.freeAttempts, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.delay(tt.failures); got != tt.want {
+				t.Errorf("delay(%d) = %s; want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPLimiter(t *testing.T) {
+	policy := throttlePolicy{freeAttempts: 2, lockoutAfter: 5, lockoutDuration: 10 * time.Minute, window: 10 * time.Minute}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	limiter := newIPLimiter(policy)
+	for i := 0; i < 2; i++ {
+		limiter.recordFailure("10.0.0.1", start)
+	}
+	if wait := limiter.retryAfter("10.0.0.1", start); wait != 0 {
+		t.Fatalf("blocked for %s after the free attempts", wait)
+	}
+
+	limiter.recordFailure("10.0.0.1", start)
+	if wait := limiter.retryAfter("10.0.0.1", start); wait != time.Second {
+		t.Errorf("blocked for %s after the first delayed failure; want 1s", wait)
+	}
+	if wait := limiter.retryAfter("10.0.0.1", start.Add(time.Second)); wait != 0 {
+		t.Errorf("still blocked for %s once the delay passed", wait)
+	}
+	if wait := limiter.retryAfter("10.0.0.2", start); wait != 0 {
+		t.Errorf("another IP is blocked for %s", wait)
+	}
+
+	for i := 0; i < 2; i++ {
+		limiter.recordFailure("10.0.0.1", start)
+	}
+	if wait := limiter.retryAfter("10.0.0.1", start); wait != policy.lockoutDuration {
+		t.Errorf("blocked for %s at lockoutAfter; want %s", wait, policy.lockoutDuration)
+	}
+
+	// a quiet IP starts over and its counter is swept
+	later := start.Add(policy.window + 2*time.Minute)
+	limiter.recordFailure("10.0.0.2", later)
+	if _, ok := limiter.attempts["10.0.0.1"]; ok {
+		t.Error("the expired counter was not swept")
+	}
+	limiter.recordFailure("10.0.0.1", later)
+	if wait := limiter.retryAfter("10.0.0.1", later); wait != 0 {
+		t.Errorf("blocked for %s on the first failure after going quiet", wait)
+	}
+}
+
+// TestLoginLocksUnknownEmailsLikeAccounts checks that repeated wrong
+// passwords get the same answers whether or not the e-mail has an account
+func TestLoginLocksUnknownEmailsLikeAccounts(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("right-password-1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := newFakeUserRepo()
+	users.add(&models.User{ID: uuid.New(), Email: "ana@example.com", PasswordHash: string(hash), Status: true})
+
+	auth := &authService{
+		userRepo:         users,
+		loginFailureRepo: newFakeLoginFailureRepo(),
+		// the IP budget must not interfere with the per e-mail one
+		ipLimiter: newIPLimiter(throttlePolicy{freeAttempts: 1000, lockoutAfter: 1000, lockoutDuration: time.Minute, window: time.Minute}),
+	}
+
+	// outcome is what a client can observe from a failed login
+	outcome := func(email string) string {
+		_, _, err := auth.Login(context.Background(), email, "wrong-password-1", "10.0.0.1")
+
+		var throttled *LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			return "throttled for " + throttled.RetryAfter.Round(time.Second).String()
+		case errors.Is(err, ErrInvalidCredentials):
+			return "invalid credentials"
+		default:
+			return "unexpected error: " + err.Error()
+		}
+	}
+
+	for attempt := 1; attempt <= accountThrottle.freeAttempts+2; attempt++ {
+		known := outcome("ana@example.com")
+		unknown := outcome("nobody@example.com")
+		if known != unknown {
+			t.Fatalf("attempt %d: known e-mail got %q but unknown one got %q", attempt, known, unknown)
+		}
+		if attempt == accountThrottle.freeAttempts+2 && known != "throttled for 1s" {
+			t.Errorf("attempt %d after a delayed failure got %q; want throttled for 1s", attempt, known)
+		}
+	}
+}
+
+// TestLoginFailuresStartOverAfterWindow checks that a lockout isn't renewed
+// by a single failure long after the previous ones, for accounts and unknown
+// e-mails alike
+func TestLoginFailuresStartOverAfterWindow(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("right-password-1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		lastFailed   time.Duration
+		wantAttempts int
+		wantLocked   bool
+	}{
+		{name: "within the window", lastFailed: time.Minute, wantAttempts: accountThrottle.lockoutAfter + 1, wantLocked: true},
+		{name: "after the window", lastFailed: accountThrottle.window + time.Minute, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			lastFailed := now.Add(-tt.lastFailed)
+			lockEnded := now.Add(-time.Second)
+
+			users := newFakeUserRepo()
+			user := &models.User{
+				ID:                  uuid.New(),
+				Email:               "ana@example.com",
+				PasswordHash:        string(hash),
+				Status:              true,
+				FailedLoginAttempts: accountThrottle.lockoutAfter,
+				LastFailedLoginAt:   &lastFailed,
+				LockedUntil:         &lockEnded,
+			}
+			users.add(user)
+			failures := newFakeLoginFailureRepo()
+			failures.failures["nobody@example.com"] = &models.LoginFailure{
+				Email:        "nobody@example.com",
+				Attempts:     accountThrottle.lockoutAfter,
+				LastFailedAt: lastFailed,
+				LockedUntil:  &lockEnded,
+			}
+
+			auth := &authService{
+				userRepo:         users,
+				loginFailureRepo: failures,
+				ipLimiter:        newIPLimiter(throttlePolicy{freeAttempts: 1000, lockoutAfter: 1000, lockoutDuration: time.Minute, window: time.Minute}),
+			}
+
+			for _, email := range []string{"ana@example.com", "nobody@example.com"} {
+				if _, _, err := auth.Login(context.Background(), email, "wrong-password-1", "10.0.0.1"); !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("%s: Login returned %v; want %v", email, err, ErrInvalidCredentials)
+				}
+			}
+
+			unknown := failures.failures["nobody@example.com"]
+			for email, got := range map[string]struct {
+				attempts    int
+				lockedUntil *time.Time
+			}{
+				"ana@example.com":    {user.FailedLoginAttempts, user.LockedUntil},
+				"nobody@example.com": {unknown.Attempts, unknown.LockedUntil},
+			} {
+				if got.attempts != tt.wantAttempts {
+					t.Errorf("%s: %d failed attempts; want %d", email, got.attempts, tt.wantAttempts)
+				}
+				if locked := got.lockedUntil != nil && got.lockedUntil.After(now); locked != tt.wantLocked {
+					t.Errorf("%s: locked = %v; want %v", email, locked, tt.wantLocked)
+				}
+			}
+		})
+	}
+}
+
+// fakeLoginFailureRepo counts failures of e-mails without an account
+type fakeLoginFailureRepo struct {
+	repository.LoginFailureRepository
+
+	mu       sync.Mutex
+	failures map[string]*models.LoginFailure
+}
+
+func newFakeLoginFailureRepo() *fakeLoginFailureRepo {
+	return &fakeLoginFailureRepo{failures: make(map[string]*models.LoginFailure)}
+}
+
+func (r *fakeLoginFailureRepo) GetByEmail(ctx context.Context, email string) (*models.LoginFailure, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failure, ok := r.failures[email]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	found := *failure
+	return &found, nil
+}
+
+func (r *fakeLoginFailureRepo) Record(ctx context.Context, email string, resetBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failure, ok := r.failures[email]
+	if !ok {
+		failure = &models.LoginFailure{Email: email}
+		r.failures[email] = failure
+	}
+	if failure.LastFailedAt.Before(resetBefore) {
+		failure.Attempts = 0
+	}
+	failure.Attempts++
+	failure.LastFailedAt = time.Now()
+	return failure.Attempts, nil
+}
+
+func (r *fakeLoginFailureRepo) LockUntil(ctx context.Context, email string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failure, ok := r.failures[email]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	failure.LockedUntil = &until
+	return nil
+}
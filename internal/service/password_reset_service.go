@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ruanv123/acme-hotel-api/internal/background"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/logger"
+	"github.com/ruanv123/acme-hotel-api/internal/mailer"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passwordResetTTL = time.Hour
+
+var (
+	ErrInvalidResetToken = errors.WithCode(errors.ErrInvalidInput, "INVALID_RESET_TOKEN", "invalid or expired password reset token")
+)
+
+type PasswordResetService interface {
+	// ForgotPassword e-mails a reset link if email belongs to an active
+	// account. It never reveals whether the account exists.
+	ForgotPassword(ctx context.Context, email string) error
+	// ResetPassword sets a new password using a token from ForgotPassword
+	// and ends every session of the user
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+type passwordResetService struct {
+	userRepo         repository.UserRepository
+	resetRepo        repository.PasswordResetRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	mailer           mailer.Mailer
+	tasks            *background.Group
+	resetURL         string
+}
+
+// NewPasswordResetService builds the reset links by appending the token as
+// the "token" query parameter of resetURL
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	mailer mailer.Mailer,
+	tasks *background.Group,
+	resetURL string,
+) PasswordResetService {
+	return &passwordResetService{
+		userRepo:         userRepo,
+		resetRepo:        resetRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		mailer:           mailer,
+		tasks:            tasks,
+		resetURL:         resetURL,
+	}
+}
+
+func (s *passwordResetService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if stderrors.Is(err, errors.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.Status {
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	err = s.resetRepo.Create(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := tokenLink(s.resetURL, token)
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"We received a request to reset your password. Use the link below within %d minutes:\n\n"+
+			"%s\n\n"+
+			"If you did not ask for a new password, you can ignore this e-mail.\n",
+			user.Name, int(passwordResetTTL.Minutes()), link),
+	}
+
+	// sent in the background so the response time doesn't reveal whether
+	// the account exists
+	s.tasks.Go(30*time.Second, func(ctx context.Context) {
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			logger.LogEvent(logrus.ErrorLevel, "Failed to send password reset e-mail", logrus.Fields{
+				"user_id": user.ID.String(),
+				"error":   err.Error(),
+			})
+		}
+	})
+
+	return nil
+}
+
+func (s *passwordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := validatePassword("password", newPassword); err != nil {
+		return err
+	}
+
+	stored, err := s.resetRepo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		if stderrors.Is(err, errors.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	// consuming the token first keeps a concurrent request from reusing it
+	if err := s.resetRepo.MarkUsed(ctx, stored.ID); err != nil {
+		if stderrors.Is(err, errors.ErrConflict) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Update(ctx, &models.User{ID: stored.UserID, PasswordHash: string(hashedPassword)}); err != nil {
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeAllForUser(ctx, stored.UserID)
+}
+
+// tokenLink appends token as the "token" query parameter of base
+func tokenLink(base, token string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid link URL %q: %v", base, err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
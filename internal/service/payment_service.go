@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+)
+
+var (
+	ErrPaymentExceedsBalance = fmt.Errorf("%w: payment exceeds the outstanding balance", errors.ErrConflict)
+	ErrReservationNotPayable = fmt.Errorf("%w: reservation does not accept payments", errors.ErrConflict)
+	ErrCurrencyMismatch      = fmt.Errorf("%w: payment currency differs from the reservation currency", errors.ErrConflict)
+)
+
+var paymentMethods = []string{
+	models.PaymentMethodCash,
+	models.PaymentMethodCreditCard,
+	models.PaymentMethodDebitCard,
+	models.PaymentMethodPix,
+	models.PaymentMethodBankTransfer,
+}
+
+// PaymentInput carries the data of a payment received by the cashier
+type PaymentInput struct {
+	AmountPaid    money.Amount
+	PaymentMethod string
+	// Currency defaults to the reservation currency when empty
+	Currency string
+	// PaymentDate defaults to today when zero
+	PaymentDate time.Time
+}
+
+// PaymentSummary is what has been paid and what is still owed on a reservation
+type PaymentSummary struct {
+	ReservationID uuid.UUID
+	Currency      string
+	TotalAmount   money.Amount
+	AmountPaid    money.Amount
+	Balance       money.Amount
+	Payments      []models.Payment
+}
+
+type PaymentService interface {
+	Record(ctx context.Context, reservationID uuid.UUID, input PaymentInput) (*models.Payment, error)
+	Summary(ctx context.Context, reservationID uuid.UUID) (*PaymentSummary, error)
+}
+
+type paymentService struct {
+	paymentRepo     repository.PaymentRepository
+	reservationRepo repository.ReservationRepository
+}
+
+func NewPaymentService(
+	paymentRepo repository.PaymentRepository,
+	reservationRepo repository.ReservationRepository,
+) PaymentService {
+	return &paymentService{
+		paymentRepo:     paymentRepo,
+		reservationRepo: reservationRepo,
+	}
+}
+
+func (s *paymentService) Record(ctx context.Context, reservationID uuid.UUID, input PaymentInput) (*models.Payment, error) {
+	input.PaymentMethod = strings.ToLower(strings.TrimSpace(input.PaymentMethod))
+	input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+	if err := validatePaymentInput(input); err != nil {
+		return nil, err
+	}
+	if input.PaymentDate.IsZero() {
+		input.PaymentDate = today()
+	}
+
+	reservation, err := s.reservationRepo.GetByID(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if input.Currency != "" && input.Currency != reservation.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	payment := &models.Payment{
+		ReservationID: reservation.ID,
+		AmountPaid:    input.AmountPaid,
+		Currency:      reservation.Currency,
+		PaymentDate:   input.PaymentDate,
+		PaymentMethod: input.PaymentMethod,
+		PaymentStatus: models.PaymentStatusPaid,
+	}
+
+	// the repository checks that the reservation still accepts payments
+	// under its lock, as it may be cancelled after the read above
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		var statusErr *repository.ReservationStatusError
+		if stderrors.As(err, &statusErr) {
+			return nil, ErrReservationNotPayable
+		}
+		if stderrors.Is(err, errors.ErrConflict) {
+			return nil, ErrPaymentExceedsBalance
+		}
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (s *paymentService) Summary(ctx context.Context, reservationID uuid.UUID) (*PaymentSummary, error) {
+	reservation, err := s.reservationRepo.GetByID(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.paymentRepo.ListByReservation(ctx, reservation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var paid money.Amount
+	for _, payment := range payments {
+		if payment.PaymentStatus == models.PaymentStatusPaid {
+			paid += payment.AmountPaid
+		}
+	}
+
+	return &PaymentSummary{
+		ReservationID: reservation.ID,
+		Currency:      reservation.Currency,
+		TotalAmount:   reservation.TotalAmount,
+		AmountPaid:    paid,
+		Balance:       reservation.TotalAmount - paid,
+		Payments:      payments,
+	}, nil
+}
+
+func validatePaymentInput(input PaymentInput) error {
+	if input.AmountPaid <= 0 {
+		return errors.NewValidationError("amount_paid", "must be greater than zero")
+	}
+	if input.Currency != "" && !money.IsCurrencyCode(input.Currency) {
+		return errors.NewValidationError("currency", "must be a three letter ISO 4217 code")
+	}
+
+	for _, method := range paymentMethods {
+		if input.PaymentMethod == method {
+			return nil
+		}
+	}
+
+	return errors.NewValidationError("payment_method", "must be one of: "+strings.Join(paymentMethods, ", "))
+}
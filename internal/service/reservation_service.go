@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+)
+
+// maxStayNights is the longest stay that can be booked or priced at once
+const maxStayNights = 365
+
+var (
+	ErrRoomUnavailable   = fmt.Errorf("%w: room is already booked for the selected dates", errors.ErrConflict)
+	ErrInvalidTransition = fmt.Errorf("%w: reservation status transition not allowed", errors.ErrConflict)
+	ErrCheckInTooEarly   = fmt.Errorf("%w: check-in is not allowed before the check-in date", errors.ErrConflict)
+	ErrRoomOccupied      = fmt.Errorf("%w: room is still occupied", errors.ErrConflict)
+	ErrRoomNotReady      = fmt.Errorf("%w: room is not ready for check-in", errors.ErrConflict)
+)
+
+// ReservationInput carries the data needed to book a room
+type ReservationInput struct {
+	GuestID      uuid.UUID
+	RoomID       uuid.UUID
+	CheckInDate  time.Time
+	CheckOutDate time.Time
+	// CreatedBy is the user making the booking
+	CreatedBy uuid.UUID
+}
+
+type ReservationService interface {
+	Create(ctx context.Context, input ReservationInput) (*models.Reservation, error)
+	List(ctx context.Context) ([]models.Reservation, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Reservation, error)
+	// Transition moves the reservation to status on behalf of userID if the
+	// lifecycle allows it
+	Transition(ctx context.Context, id uuid.UUID, status string, userID uuid.UUID) (*models.Reservation, error)
+	History(ctx context.Context, id uuid.UUID) ([]models.ReservationStatusChange, error)
+}
+
+type reservationService struct {
+	reservationRepo repository.ReservationRepository
+	guestRepo       repository.GuestRepository
+	roomRepo        repository.RoomRepository
+}
+
+func NewReservationService(
+	reservationRepo repository.ReservationRepository,
+	guestRepo repository.GuestRepository,
+	roomRepo repository.RoomRepository,
+) ReservationService {
+	return &reservationService{
+		reservationRepo: reservationRepo,
+		guestRepo:       guestRepo,
+		roomRepo:        roomRepo,
+	}
+}
+
+func (s *reservationService) Create(ctx context.Context, input ReservationInput) (*models.Reservation, error) {
+	if err := validateStayDates(input.CheckInDate, input.CheckOutDate); err != nil {
+		return nil, err
+	}
+	if input.CheckInDate.Before(today()) {
+		return nil, errors.NewValidationError("check_in_date", "must not be in the past")
+	}
+
+	guest, err := s.guestRepo.GetByID(ctx, input.GuestID)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewValidationError("guest_id", "guest does not exist")
+		}
+		return nil, err
+	}
+
+	room, err := s.roomRepo.GetByID(ctx, input.RoomID)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewValidationError("room_id", "room does not exist")
+		}
+		return nil, err
+	}
+	if room.Status == models.RoomStatusRetired {
+		return nil, errors.NewValidationError("room_id", "room has been retired")
+	}
+
+	total, err := stayPrice(room.DailyRate, stayNights(input.CheckInDate, input.CheckOutDate))
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := &models.Reservation{
+		GuestID:      guest.ID,
+		RoomID:       room.ID,
+		CheckInDate:  input.CheckInDate,
+		CheckOutDate: input.CheckOutDate,
+		TotalAmount:  total,
+		Currency:     room.Currency,
+		Status:       models.ReservationStatusPending,
+	}
+
+	change := &models.ReservationStatusChange{
+		ToStatus:    models.ReservationStatusPending,
+		ChangedByID: input.CreatedBy,
+		ChangedAt:   time.Now(),
+	}
+
+	if err := s.reservationRepo.Create(ctx, reservation, change); err != nil {
+		if stderrors.Is(err, errors.ErrConflict) {
+			return nil, ErrRoomUnavailable
+		}
+		return nil, err
+	}
+
+	reservation.Guest = *guest
+	reservation.Room = *room
+
+	return reservation, nil
+}
+
+func (s *reservationService) List(ctx context.Context) ([]models.Reservation, error) {
+	return s.reservationRepo.ListAll(ctx)
+}
+
+func (s *reservationService) GetByID(ctx context.Context, id uuid.UUID) (*models.Reservation, error) {
+	return s.reservationRepo.GetByID(ctx, id)
+}
+
+func (s *reservationService) Transition(ctx context.Context, id uuid.UUID, status string, userID uuid.UUID) (*models.Reservation, error) {
+	reservation, err := s.reservationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reservation.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: cannot move from %s to %s", ErrInvalidTransition, reservation.Status, status)
+	}
+
+	// check-in and check-out also drive the room status, in the same
+	// transaction as the reservation update. Only an available room can be
+	// checked into; the repository checks it under a row lock. Check-out
+	// never fails on the room: an occupied room needs cleaning, but one that
+	// staff moved to maintenance or retired meanwhile keeps that status.
+	var roomUpdate *repository.RoomStatusUpdate
+	switch status {
+	case models.ReservationStatusCheckedIn:
+		if today().Before(reservation.CheckInDate) {
+			return nil, ErrCheckInTooEarly
+		}
+		roomUpdate = &repository.RoomStatusUpdate{
+			From: models.RoomStatusAvailable,
+			To:   models.RoomStatusOccupied,
+		}
+	case models.ReservationStatusCheckedOut:
+		roomUpdate = &repository.RoomStatusUpdate{
+			From:        models.RoomStatusOccupied,
+			To:          models.RoomStatusDirty,
+			LeaveOthers: true,
+		}
+	}
+
+	change := &models.ReservationStatusChange{
+		FromStatus:  reservation.Status,
+		ToStatus:    status,
+		ChangedByID: userID,
+		ChangedAt:   time.Now(),
+	}
+
+	if err := s.reservationRepo.UpdateStatus(ctx, reservation, change, roomUpdate); err != nil {
+		var roomErr *repository.RoomStatusError
+		if stderrors.As(err, &roomErr) {
+			if roomErr.Status == models.RoomStatusOccupied {
+				return nil, ErrRoomOccupied
+			}
+			return nil, fmt.Errorf("%w: room is %s", ErrRoomNotReady, roomErr.Status)
+		}
+		if stderrors.Is(err, errors.ErrConflict) {
+			return nil, fmt.Errorf("%w: reservation was modified concurrently", ErrInvalidTransition)
+		}
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+func (s *reservationService) History(ctx context.Context, id uuid.UUID) ([]models.ReservationStatusChange, error) {
+	if _, err := s.reservationRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.reservationRepo.ListStatusChanges(ctx, id)
+}
+
+// validateStayDates checks that the stay covers at least one night and at
+// most maxStayNights
+func validateStayDates(checkIn, checkOut time.Time) error {
+	if checkIn.IsZero() {
+		return errors.NewValidationError("check_in_date", "is required")
+	}
+	if checkOut.IsZero() {
+		return errors.NewValidationError("check_out_date", "is required")
+	}
+	if !checkOut.After(checkIn) {
+		return errors.NewValidationError("check_out_date", "must be after check_in_date")
+	}
+	if stayNights(checkIn, checkOut) > maxStayNights {
+		return errors.NewValidationError("check_out_date", fmt.Sprintf("must be at most %d nights after check_in_date", maxStayNights))
+	}
+
+	return nil
+}
+
+// stayNights returns the number of nights between two calendar dates
+func stayNights(checkIn, checkOut time.Time) int {
+	return int(checkOut.Sub(checkIn).Hours() / 24)
+}
+
+// stayPrice is the total of a stay of nights at the daily rate
+func stayPrice(rate money.Amount, nights int) (money.Amount, error) {
+	total, err := rate.Mul(nights)
+	if err != nil {
+		return 0, errors.NewValidationError("check_out_date", "makes the total price larger than "+money.Max.String())
+	}
+	return total, nil
+}
+
+// today returns the current calendar date in the same UTC midnight form
+// used for dates parsed from requests
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
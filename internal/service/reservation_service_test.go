@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+)
+
+func TestReservationTransition(t *testing.T) {
+	tomorrow := today().AddDate(0, 0, 1)
+
+	tests := []struct {
+		name             string
+		from             string
+		to               string
+		roomStatus       string
+		checkInTomorrow  bool
+		concurrentStatus string
+		wantErr          error
+		wantRoomStatus   string
+	}{
+		{name: "confirm", from: models.ReservationStatusPending, to: models.ReservationStatusConfirmed, roomStatus: models.RoomStatusDirty, wantRoomStatus: models.RoomStatusDirty},
+		{name: "cancel pending", from: models.ReservationStatusPending, to: models.ReservationStatusCancelled, roomStatus: models.RoomStatusAvailable, wantRoomStatus: models.RoomStatusAvailable},
+		{name: "no-show", from: models.ReservationStatusConfirmed, to: models.ReservationStatusNoShow, roomStatus: models.RoomStatusAvailable, wantRoomStatus: models.RoomStatusAvailable},
+		{name: "check in", from: models.ReservationStatusConfirmed, to: models.ReservationStatusCheckedIn, roomStatus: models.RoomStatusAvailable, wantRoomStatus: models.RoomStatusOccupied},
+		{name: "check out", from: models.ReservationStatusCheckedIn, to: models.ReservationStatusCheckedOut, roomStatus: models.RoomStatusOccupied, wantRoomStatus: models.RoomStatusDirty},
+		{name: "check out of a room under maintenance", from: models.ReservationStatusCheckedIn, to: models.ReservationStatusCheckedOut, roomStatus: models.RoomStatusMaintenance, wantRoomStatus: models.RoomStatusMaintenance},
+		{name: "check out of a retired room", from: models.ReservationStatusCheckedIn, to: models.ReservationStatusCheckedOut, roomStatus: models.RoomStatusRetired, wantRoomStatus: models.RoomStatusRetired},
+
+		{name: "check in before the date", from: models.ReservationStatusConfirmed, to: models.ReservationStatusCheckedIn, roomStatus: models.RoomStatusAvailable, checkInTomorrow: true, wantErr: ErrCheckInTooEarly, wantRoomStatus: models.RoomStatusAvailable},
+		{name: "check in to an occupied room", from: models.ReservationStatusConfirmed, to: models.ReservationStatusCheckedIn, roomStatus: models.RoomStatusOccupied, wantErr: ErrRoomOccupied, wantRoomStatus: models.RoomStatusOccupied},
+		{name: "check in to a dirty room", from: models.ReservationStatusConfirmed, to: models.ReservationStatusCheckedIn, roomStatus: models.RoomStatusDirty, wantErr: ErrRoomNotReady, wantRoomStatus: models.RoomStatusDirty},
+		{name: "check in to a room under maintenance", from: models.ReservationStatusConfirmed, to: models.ReservationStatusCheckedIn, roomStatus: models.RoomStatusMaintenance, wantErr: ErrRoomNotReady, wantRoomStatus: models.RoomStatusMaintenance},
+		{name: "check in to a retired room", from: models.ReservationStatusConfirmed, to: models.ReservationStatusCheckedIn, roomStatus: models.RoomStatusRetired, wantErr: ErrRoomNotReady, wantRoomStatus: models.RoomStatusRetired},
+
+		{name: "check in a pending reservation", from: models.ReservationStatusPending, to: models.ReservationStatusCheckedIn, roomStatus: models.RoomStatusAvailable, wantErr: ErrInvalidTransition, wantRoomStatus: models.RoomStatusAvailable},
+		{name: "check out without checking in", from: models.ReservationStatusConfirmed, to: models.ReservationStatusCheckedOut, roomStatus: models.RoomStatusAvailable, wantErr: ErrInvalidTransition, wantRoomStatus: models.RoomStatusAvailable},
+		{name: "reopen a cancelled reservation", from: models.ReservationStatusCancelled, to: models.ReservationStatusConfirmed, roomStatus: models.RoomStatusAvailable, wantErr: ErrInvalidTransition, wantRoomStatus: models.RoomStatusAvailable},
+		{name: "cancel after checking out", from: models.ReservationStatusCheckedOut, to: models.ReservationStatusCancelled, roomStatus: models.RoomStatusDirty, wantErr: ErrInvalidTransition, wantRoomStatus: models.RoomStatusDirty},
+		{name: "cancelled concurrently", from: models.ReservationStatusPending, to: models.ReservationStatusConfirmed, roomStatus: models.RoomStatusAvailable, concurrentStatus: models.ReservationStatusCancelled, wantErr: ErrInvalidTransition, wantRoomStatus: models.RoomStatusAvailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkIn := today()
+			if tt.checkInTomorrow {
+				checkIn = tomorrow
+			}
+			reservation := &models.Reservation{
+				ID:           uuid.New(),
+				RoomID:       uuid.New(),
+				CheckInDate:  checkIn,
+				CheckOutDate: checkIn.AddDate(0, 0, 2),
+				Status:       tt.from,
+			}
+			repo := &fakeReservationRepo{
+				reservation:      reservation,
+				roomStatus:       tt.roomStatus,
+				concurrentStatus: tt.concurrentStatus,
+			}
+			service := &reservationService{reservationRepo: repo}
+			staff := uuid.New()
+
+			updated, err := service.Transition(context.Background(), reservation.ID, tt.to, staff)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Transition returned %v; want %v", err, tt.wantErr)
+				}
+				if !errors.Is(err, apperrors.ErrConflict) {
+					t.Errorf("error %v is not a conflict", err)
+				}
+				if len(repo.changes) != 0 {
+					t.Errorf("a refused transition recorded %d changes", len(repo.changes))
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Transition returned %v", err)
+				}
+				if updated.Status != tt.to {
+					t.Errorf("reservation status = %s; want %s", updated.Status, tt.to)
+				}
+				want := models.ReservationStatusChange{ReservationID: reservation.ID, FromStatus: tt.from, ToStatus: tt.to, ChangedByID: staff}
+				if len(repo.changes) != 1 {
+					t.Fatalf("recorded %d changes; want 1", len(repo.changes))
+				}
+				got := repo.changes[0]
+				got.ChangedAt = want.ChangedAt
+				if got != want {
+					t.Errorf("recorded change %+v; want %+v", got, want)
+				}
+			}
+
+			if repo.roomStatus != tt.wantRoomStatus {
+				t.Errorf("room status = %s; want %s", repo.roomStatus, tt.wantRoomStatus)
+			}
+		})
+	}
+}
+
+func TestReservationTransitionUnknownReservation(t *testing.T) {
+	service := &reservationService{reservationRepo: &fakeReservationRepo{}}
+
+	_, err := service.Transition(context.Background(), uuid.New(), models.ReservationStatusConfirmed, uuid.New())
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Transition returned %v; want %v", err, apperrors.ErrNotFound)
+	}
+}
+
+// fakeReservationRepo holds a single reservation and the status of its room
+type fakeReservationRepo struct {
+	repository.ReservationRepository
+
+	reservation *models.Reservation
+	roomStatus  string
+	changes     []models.ReservationStatusChange
+	// concurrentStatus, when set, is the status another request moved the
+	// reservation to before UpdateStatus runs
+	concurrentStatus string
+}
+
+func (r *fakeReservationRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Reservation, error) {
+	if r.reservation == nil || r.reservation.ID != id {
+		return nil, apperrors.ErrNotFound
+	}
+	found := *r.reservation
+	return &found, nil
+}
+
+func (r *fakeReservationRepo) UpdateStatus(ctx context.Context, reservation *models.Reservation, change *models.ReservationStatusChange, room *repository.RoomStatusUpdate) error {
+	if r.concurrentStatus != "" {
+		r.reservation.Status = r.concurrentStatus
+	}
+
+	if room != nil && r.roomStatus != room.From {
+		if !room.LeaveOthers {
+			return &repository.RoomStatusError{Status: r.roomStatus}
+		}
+		room = nil
+	}
+	if r.reservation.Status != change.FromStatus {
+		return apperrors.ErrConflict
+	}
+
+	r.reservation.Status = change.ToStatus
+	change.ReservationID = reservation.ID
+	r.changes = append(r.changes, *change)
+	if room != nil {
+		r.roomStatus = room.To
+		reservation.Room.Status = room.To
+	}
+	reservation.Status = change.ToStatus
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// ErrLastUserManager is returned for a change that would leave no active
+// user able to manage users, and so nobody able to undo it
+var ErrLastUserManager = errors.WithCode(errors.ErrConflict, "LAST_USER_MANAGER",
+	"at least one active user must keep the "+models.PermissionUsersManage+" permission")
+
+// RoleInput carries the data of a role created by an admin
+type RoleInput struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	ListPermissions(ctx context.Context) ([]models.Permission, error)
+	CreateRole(ctx context.Context, input RoleInput) (*models.Role, error)
+	SetRolePermissions(ctx context.Context, name string, permissions []string) (*models.Role, error)
+	// AssignRole gives the user the named role on behalf of actorID
+	AssignRole(ctx context.Context, actorID, userID uuid.UUID, role string) error
+	HasPermission(ctx context.Context, user *models.User, permission string) (bool, error)
+}
+
+type roleService struct {
+	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository) RoleService {
+	return &roleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+func (s *roleService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	return s.roleRepo.ListAll(ctx)
+}
+
+func (s *roleService) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	return s.roleRepo.ListPermissions(ctx)
+}
+
+func (s *roleService) CreateRole(ctx context.Context, input RoleInput) (*models.Role, error) {
+	input.Name = normalizeRoleName(input.Name)
+	input.Description = strings.TrimSpace(input.Description)
+
+	if !roleNamePattern.MatchString(input.Name) {
+		return nil, errors.NewValidationError("name", "must be 2 to 50 lowercase letters, digits or underscores")
+	}
+	if len(input.Description) > 255 {
+		return nil, errors.NewValidationError("description", "must be at most 255 bytes")
+	}
+
+	permissions, err := s.resolvePermissions(ctx, input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        input.Name,
+		Description: input.Description,
+		Permissions: permissions,
+	}
+
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *roleService) SetRolePermissions(ctx context.Context, name string, codes []string) (*models.Role, error) {
+	role, err := s.roleRepo.GetByName(ctx, normalizeRoleName(name))
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.resolvePermissions(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.ReplacePermissions(ctx, role, permissions); err != nil {
+		return nil, lastUserManager(err)
+	}
+	role.Permissions = permissions
+
+	return role, nil
+}
+
+func (s *roleService) AssignRole(ctx context.Context, actorID, userID uuid.UUID, name string) error {
+	// an admin demoting themselves could leave nobody able to manage users
+	if actorID == userID {
+		return errors.NewValidationError("id", "you cannot change your own role")
+	}
+
+	role, err := s.roleRepo.GetByName(ctx, normalizeRoleName(name))
+	if err != nil {
+		if stderrors.Is(err, errors.ErrNotFound) {
+			return errors.NewValidationError("role", "role does not exist")
+		}
+		return err
+	}
+
+	return lastUserManager(s.userRepo.SetRole(ctx, userID, role.Name))
+}
+
+func (s *roleService) HasPermission(ctx context.Context, user *models.User, permission string) (bool, error) {
+	return s.roleRepo.HasPermission(ctx, user.Role, permission)
+}
+
+// lastUserManager turns the conflict the repository reports for a change
+// leaving nobody with users:manage into ErrLastUserManager
+func lastUserManager(err error) error {
+	if stderrors.Is(err, errors.ErrConflict) {
+		return ErrLastUserManager
+	}
+	return err
+}
+
+// normalizeRoleName is the form role names are stored and looked up in
+func normalizeRoleName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// resolvePermissions loads the permissions for codes, rejecting unknown ones
+func (s *roleService) resolvePermissions(ctx context.Context, codes []string) ([]models.Permission, error) {
+	permissions, err := s.roleRepo.GetPermissionsByCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		known[permission.Code] = true
+	}
+	for _, code := range codes {
+		if !known[code] {
+			return nil, errors.NewValidationError("permissions", "unknown permission "+code)
+		}
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+)
+
+func TestSetRolePermissionsNormalizesName(t *testing.T) {
+	roles := newFakeRoleRepo()
+	roles.add("front_desk", models.PermissionRoomsRead)
+	svc := NewRoleService(roles, nil)
+
+	for _, name := range []string{"front_desk", "Front_Desk", " FRONT_DESK "} {
+		role, err := svc.SetRolePermissions(context.Background(), name, []string{models.PermissionGuestsRead})
+		if err != nil {
+			t.Fatalf("SetRolePermissions(%q) returned %v", name, err)
+		}
+		if role.Name != "front_desk" {
+			t.Errorf("SetRolePermissions(%q) changed role %q; want front_desk", name, role.Name)
+		}
+	}
+	if len(roles.roles) != 1 {
+		t.Errorf("%d roles exist; want 1", len(roles.roles))
+	}
+}
+
+// fakeRoleRepo keeps roles and the permission codes they grant in memory;
+// the embedded interface panics on any other call
+type fakeRoleRepo struct {
+	repository.RoleRepository
+
+	roles map[string]*models.Role
+}
+
+func newFakeRoleRepo() *fakeRoleRepo {
+	return &fakeRoleRepo{roles: make(map[string]*models.Role)}
+}
+
+func (r *fakeRoleRepo) add(name string, codes ...string) {
+	role := &models.Role{ID: uuid.New(), Name: name}
+	for _, code := range codes {
+		role.Permissions = append(role.Permissions, models.Permission{ID: uuid.New(), Code: code})
+	}
+	r.roles[name] = role
+}
+
+func (r *fakeRoleRepo) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	copied := *role
+	return &copied, nil
+}
+
+func (r *fakeRoleRepo) GetPermissionsByCodes(ctx context.Context, codes []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	for _, code := range codes {
+		permissions = append(permissions, models.Permission{ID: uuid.New(), Code: code})
+	}
+	return permissions, nil
+}
+
+func (r *fakeRoleRepo) ReplacePermissions(ctx context.Context, role *models.Role, permissions []models.Permission) error {
+	r.roles[role.Name].Permissions = permissions
+	return nil
+}
+
+func (r *fakeRoleRepo) HasPermission(ctx context.Context, roleName, code string) (bool, error) {
+	role, ok := r.roles[roleName]
+	if !ok {
+		return false, nil
+	}
+	for _, permission := range role.Permissions {
+		if permission.Code == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+)
+
+// RoomInput carries the editable fields of a room
+type RoomInput struct {
+	Number    int
+	Type      string
+	Capacity  int
+	DailyRate money.Amount
+	// Currency defaults to money.DefaultCurrency when empty
+	Currency string
+	Status   string
+}
+
+// AvailabilityQuery describes the stay a guest is looking for
+type AvailabilityQuery struct {
+	CheckInDate  time.Time
+	CheckOutDate time.Time
+	Guests       int
+	Type         string
+}
+
+// RoomAvailability is a free room together with the price of the stay
+type RoomAvailability struct {
+	Room       models.Room
+	Nights     int
+	TotalPrice money.Amount
+}
+
+type RoomService interface {
+	Create(ctx context.Context, input RoomInput) (*models.Room, error)
+	List(ctx context.Context) ([]models.Room, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error)
+	Update(ctx context.Context, id uuid.UUID, input RoomInput) (*models.Room, error)
+	Retire(ctx context.Context, id uuid.UUID) (*models.Room, error)
+	// MarkCleaned makes a room left dirty by a check-out available again
+	MarkCleaned(ctx context.Context, id uuid.UUID) (*models.Room, error)
+	Availability(ctx context.Context, query AvailabilityQuery) ([]RoomAvailability, error)
+}
+
+type roomService struct {
+	roomRepo repository.RoomRepository
+}
+
+func NewRoomService(roomRepo repository.RoomRepository) RoomService {
+	return &roomService{
+		roomRepo: roomRepo,
+	}
+}
+
+func (s *roomService) Create(ctx context.Context, input RoomInput) (*models.Room, error) {
+	input, err := normalizeRoomInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if input.Status == "" {
+		input.Status = models.RoomStatusAvailable
+	}
+	if input.Currency == "" {
+		input.Currency = money.DefaultCurrency
+	}
+
+	room := &models.Room{
+		Number:    input.Number,
+		Type:      input.Type,
+		Capacity:  input.Capacity,
+		DailyRate: input.DailyRate,
+		Currency:  input.Currency,
+		Status:    input.Status,
+	}
+
+	if err := s.roomRepo.Create(ctx, room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (s *roomService) List(ctx context.Context) ([]models.Room, error) {
+	return s.roomRepo.ListAll(ctx)
+}
+
+func (s *roomService) GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error) {
+	return s.roomRepo.GetByID(ctx, id)
+}
+
+func (s *roomService) Update(ctx context.Context, id uuid.UUID, input RoomInput) (*models.Room, error) {
+	input, err := normalizeRoomInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	room, err := s.roomRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	room.Number = input.Number
+	room.Type = input.Type
+	room.Capacity = input.Capacity
+	room.DailyRate = input.DailyRate
+	if input.Currency != "" {
+		room.Currency = input.Currency
+	}
+	// occupancy is driven by check-in/check-out, so the status is only
+	// changed when explicitly requested, and only from the status just read
+	var status *repository.RoomStatusUpdate
+	if input.Status != "" && input.Status != room.Status {
+		status = &repository.RoomStatusUpdate{From: room.Status, To: input.Status}
+	}
+
+	if err := s.roomRepo.Update(ctx, room, status); err != nil {
+		return nil, roomStatusChanged(err)
+	}
+
+	return room, nil
+}
+
+// Retire takes a room out of the inventory while keeping it around for the
+// reservations that reference it
+func (s *roomService) Retire(ctx context.Context, id uuid.UUID) (*models.Room, error) {
+	room, err := s.roomRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if room.Status == models.RoomStatusRetired {
+		return room, nil
+	}
+
+	update := repository.RoomStatusUpdate{From: room.Status, To: models.RoomStatusRetired}
+	if err := s.roomRepo.SetStatus(ctx, id, update); err != nil {
+		return nil, roomStatusChanged(err)
+	}
+	room.Status = update.To
+
+	return room, nil
+}
+
+func (s *roomService) MarkCleaned(ctx context.Context, id uuid.UUID) (*models.Room, error) {
+	room, err := s.roomRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := repository.RoomStatusUpdate{From: models.RoomStatusDirty, To: models.RoomStatusAvailable}
+	if err := s.roomRepo.SetStatus(ctx, id, update); err != nil {
+		var roomErr *repository.RoomStatusError
+		if stderrors.As(err, &roomErr) {
+			return nil, fmt.Errorf("%w: room is %s, not dirty", errors.ErrConflict, roomErr.Status)
+		}
+		return nil, err
+	}
+	room.Status = update.To
+
+	return room, nil
+}
+
+func (s *roomService) Availability(ctx context.Context, query AvailabilityQuery) ([]RoomAvailability, error) {
+	if err := validateStayDates(query.CheckInDate, query.CheckOutDate); err != nil {
+		return nil, err
+	}
+	if query.CheckInDate.Before(today()) {
+		return nil, errors.NewValidationError("check_in_date", "must not be in the past")
+	}
+	if query.Guests <= 0 {
+		return nil, errors.NewValidationError("guests", "must be at least 1")
+	}
+
+	rooms, err := s.roomRepo.ListAvailable(ctx, query.CheckInDate, query.CheckOutDate, query.Guests, strings.TrimSpace(query.Type))
+	if err != nil {
+		return nil, err
+	}
+
+	nights := stayNights(query.CheckInDate, query.CheckOutDate)
+	availability := make([]RoomAvailability, 0, len(rooms))
+	for _, room := range rooms {
+		total, err := stayPrice(room.DailyRate, nights)
+		if err != nil {
+			return nil, err
+		}
+		availability = append(availability, RoomAvailability{
+			Room:       room,
+			Nights:     nights,
+			TotalPrice: total,
+		})
+	}
+
+	return availability, nil
+}
+
+// roomStatusChanged explains a status update refused because a check-in,
+// check-out or another request changed the room after it was read
+func roomStatusChanged(err error) error {
+	var roomErr *repository.RoomStatusError
+	if stderrors.As(err, &roomErr) {
+		return fmt.Errorf("%w: room status changed to %s meanwhile", errors.ErrConflict, roomErr.Status)
+	}
+	return err
+}
+
+func normalizeRoomInput(input RoomInput) (RoomInput, error) {
+	input.Type = strings.TrimSpace(input.Type)
+	input.Status = strings.ToLower(strings.TrimSpace(input.Status))
+	input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+
+	if input.Number <= 0 {
+		return input, errors.NewValidationError("number", "must be a positive number")
+	}
+	if input.Type == "" {
+		return input, errors.NewValidationError("type", "is required")
+	}
+	if len(input.Type) > 50 {
+		return input, errors.NewValidationError("type", "must be at most 50 bytes")
+	}
+	if input.Capacity <= 0 {
+		return input, errors.NewValidationError("capacity", "must be at least 1")
+	}
+	if input.DailyRate <= 0 {
+		return input, errors.NewValidationError("daily_rate", "must be greater than zero")
+	}
+	if input.Currency != "" && !money.IsCurrencyCode(input.Currency) {
+		return input, errors.NewValidationError("currency", "must be a three letter ISO 4217 code")
+	}
+	switch input.Status {
+	case "", models.RoomStatusAvailable, models.RoomStatusDirty, models.RoomStatusMaintenance:
+	default:
+		return input, errors.NewValidationError("status", "must be one of: available, dirty, maintenance")
+	}
+
+	return input, nil
+}
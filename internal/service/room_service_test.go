@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/ruanv123/acme-hotel-api/internal/errors"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/money"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+)
+
+func TestRoomStatusChanges(t *testing.T) {
+	update := func(status string) func(s *roomService, id uuid.UUID) (*models.Room, error) {
+		return func(s *roomService, id uuid.UUID) (*models.Room, error) {
+			return s.Update(context.Background(), id, RoomInput{Number: 101, Type: "double", Capacity: 2, DailyRate: 10000, Status: status})
+		}
+	}
+	retire := func(s *roomService, id uuid.UUID) (*models.Room, error) {
+		return s.Retire(context.Background(), id)
+	}
+	markCleaned := func(s *roomService, id uuid.UUID) (*models.Room, error) {
+		return s.MarkCleaned(context.Background(), id)
+	}
+
+	tests := []struct {
+		name string
+		call func(s *roomService, id uuid.UUID) (*models.Room, error)
+		// status is the room status the service reads; concurrentStatus,
+		// when set, is the status a check-in or check-out moves the room to
+		// right after that read
+		status           string
+		concurrentStatus string
+		wantErr          error
+		wantStatus       string
+	}{
+		{name: "edit keeps the status", call: update(""), status: models.RoomStatusAvailable, wantStatus: models.RoomStatusAvailable},
+		{name: "edit keeps a concurrent check-in", call: update(""), status: models.RoomStatusAvailable, concurrentStatus: models.RoomStatusOccupied, wantStatus: models.RoomStatusOccupied},
+		{name: "edit sets the status", call: update(models.RoomStatusMaintenance), status: models.RoomStatusAvailable, wantStatus: models.RoomStatusMaintenance},
+		{name: "edit refuses a status changed meanwhile", call: update(models.RoomStatusAvailable), status: models.RoomStatusDirty, concurrentStatus: models.RoomStatusOccupied, wantErr: apperrors.ErrConflict, wantStatus: models.RoomStatusOccupied},
+
+		{name: "retire", call: retire, status: models.RoomStatusAvailable, wantStatus: models.RoomStatusRetired},
+		{name: "retire a retired room", call: retire, status: models.RoomStatusRetired, wantStatus: models.RoomStatusRetired},
+		{name: "retire refuses a room checked into meanwhile", call: retire, status: models.RoomStatusAvailable, concurrentStatus: models.RoomStatusOccupied, wantErr: apperrors.ErrConflict, wantStatus: models.RoomStatusOccupied},
+
+		{name: "clean a dirty room", call: markCleaned, status: models.RoomStatusDirty, wantStatus: models.RoomStatusAvailable},
+		{name: "clean an occupied room", call: markCleaned, status: models.RoomStatusOccupied, wantErr: apperrors.ErrConflict, wantStatus: models.RoomStatusOccupied},
+		{name: "clean a room put under maintenance meanwhile", call: markCleaned, status: models.RoomStatusDirty, concurrentStatus: models.RoomStatusMaintenance, wantErr: apperrors.ErrConflict, wantStatus: models.RoomStatusMaintenance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRoomRepo{
+				room:             &models.Room{ID: uuid.New(), Number: 101, Type: "double", Capacity: 2, DailyRate: 10000, Currency: "BRL", Status: tt.status},
+				concurrentStatus: tt.concurrentStatus,
+			}
+			service := &roomService{roomRepo: repo}
+
+			room, err := tt.call(service, repo.room.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("returned %v; want %v", err, tt.wantErr)
+			}
+			if err == nil && room.Status != repo.room.Status {
+				t.Errorf("returned a room %s; stored one is %s", room.Status, repo.room.Status)
+			}
+			if repo.room.Status != tt.wantStatus {
+				t.Errorf("room status = %s; want %s", repo.room.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	nextWeek := today().AddDate(0, 0, 7)
+
+	tests := []struct {
+		name      string
+		checkIn   time.Time
+		rate      money.Amount
+		nights    int
+		wantField string
+		wantTotal money.Amount
+	}{
+		{name: "three nights", rate: 15050, nights: 3, wantTotal: 45150},
+		{name: "the longest stay", rate: 10000, nights: maxStayNights, wantTotal: 10000 * maxStayNights},
+		{name: "longer than the longest stay", rate: 10000, nights: maxStayNights + 1, wantField: "check_out_date"},
+		{name: "total beyond the largest amount", rate: money.Max, nights: 2, wantField: "check_out_date"},
+		{name: "checking in today", checkIn: today(), rate: 10000, nights: 1, wantTotal: 10000},
+		{name: "checking in yesterday", checkIn: today().AddDate(0, 0, -1), rate: 10000, nights: 2, wantField: "check_in_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkIn := tt.checkIn
+			if checkIn.IsZero() {
+				checkIn = nextWeek
+			}
+			rooms := &fakeRoomRepo{room: &models.Room{ID: uuid.New(), Capacity: 2, DailyRate: tt.rate}}
+			svc := &roomService{roomRepo: rooms}
+
+			availability, err := svc.Availability(context.Background(), AvailabilityQuery{
+				CheckInDate:  checkIn,
+				CheckOutDate: checkIn.AddDate(0, 0, tt.nights),
+				Guests:       1,
+			})
+			if tt.wantField != "" {
+				var validationErr *apperrors.ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Field != tt.wantField {
+					t.Fatalf("Availability returned %v; want a validation error on %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Availability returned %v", err)
+			}
+			if len(availability) != 1 || availability[0].TotalPrice != tt.wantTotal {
+				t.Errorf("availability = %+v; want one room at %s", availability, tt.wantTotal)
+			}
+		})
+	}
+}
+
+// fakeRoomRepo holds a single room
+type fakeRoomRepo struct {
+	repository.RoomRepository
+
+	room *models.Room
+	// concurrentStatus, when set, is the status another request moves the
+	// room to right after GetByID
+	concurrentStatus string
+}
+
+func (r *fakeRoomRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error) {
+	if r.room.ID != id {
+		return nil, apperrors.ErrNotFound
+	}
+	found := *r.room
+	if r.concurrentStatus != "" {
+		r.room.Status = r.concurrentStatus
+	}
+	return &found, nil
+}
+
+func (r *fakeRoomRepo) ListAvailable(ctx context.Context, checkIn, checkOut time.Time, minCapacity int, roomType string) ([]models.Room, error) {
+	return []models.Room{*r.room}, nil
+}
+
+func (r *fakeRoomRepo) Update(ctx context.Context, room *models.Room, status *repository.RoomStatusUpdate) error {
+	if status != nil {
+		if err := r.SetStatus(ctx, room.ID, *status); err != nil {
+			return err
+		}
+	}
+
+	// like the real one, the update leaves the status alone and refreshes
+	// the caller's copy
+	room.Status = r.room.Status
+	*r.room = *room
+	return nil
+}
+
+func (r *fakeRoomRepo) SetStatus(ctx context.Context, id uuid.UUID, update repository.RoomStatusUpdate) error {
+	if r.room.Status != update.From {
+		return &repository.RoomStatusError{Status: r.room.Status}
+	}
+	r.room.Status = update.To
+	return nil
+}
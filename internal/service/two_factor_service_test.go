@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/models"
+	"github.com/ruanv123/acme-hotel-api/internal/repository"
+	"github.com/ruanv123/acme-hotel-api/internal/totp"
+)
+
+func TestSecondFactorTOTPReplay(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{ID: uuid.New(), TOTPSecret: secret, TOTPEnabled: true}
+
+	// check reads the clock itself, so keep the whole test within one step
+	if untilNext := 30 - time.Now().Unix()%30; untilNext < 2 {
+		time.Sleep(time.Duration(untilNext) * time.Second)
+	}
+
+	codeAt := func(offset int64) string {
+		code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	// each attempt runs against the steps used by the ones before it
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"previous step is accepted", codeAt(-1), nil},
+		{"same code again is refused", codeAt(-1), ErrInvalidTwoFactorCode},
+		{"current step is accepted", codeAt(0), nil},
+		{"current code replayed is refused", codeAt(0), ErrInvalidTwoFactorCode},
+		{"older step than the last used is refused", codeAt(-1), ErrInvalidTwoFactorCode},
+		{"wrong code is refused", "000000", ErrInvalidTwoFactorCode},
+		{"next step is accepted", codeAt(1), nil},
+	}
+
+	factor := secondFactor{userRepo: newFakeTOTPStepRepo(), recoveryCodeRepo: newFakeRecoveryCodeRepo()}
+	for _, tt := range tests {
+		err := factor.check(context.Background(), user, tt.code)
+		if tt.wantErr == nil && err != nil {
+			t.Errorf("%s: check returned %v", tt.name, err)
+		}
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: check returned %v; want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSecondFactorRecoveryCodes(t *testing.T) {
+	user := &models.User{ID: uuid.New(), TOTPEnabled: true}
+
+	code, err := newRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes := newFakeRecoveryCodeRepo()
+	recoveryCodes.Replace(context.Background(), user.ID, []string{hashToken(normalizeRecoveryCode(code))})
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"wrong code is refused", "aaaaa-aaaaa", ErrInvalidTwoFactorCode},
+		{"malformed code is refused", "abc", ErrInvalidTwoFactorCode},
+		{"code is accepted in any case", " " + strings.ToUpper(code) + " ", nil},
+		{"used code is refused", code, ErrInvalidTwoFactorCode},
+	}
+
+	factor := secondFactor{userRepo: newFakeTOTPStepRepo(), recoveryCodeRepo: recoveryCodes}
+	for _, tt := range tests {
+		err := factor.check(context.Background(), user, tt.code)
+		if tt.wantErr == nil && err != nil {
+			t.Errorf("%s: check returned %v", tt.name, err)
+		}
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: check returned %v; want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// fakeTOTPStepRepo remembers the last TOTP step used by each account
+type fakeTOTPStepRepo struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	steps map[uuid.UUID]int64
+}
+
+func newFakeTOTPStepRepo() *fakeTOTPStepRepo {
+	return &fakeTOTPStepRepo{steps: make(map[uuid.UUID]int64)}
+}
+
+func (r *fakeTOTPStepRepo) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last, ok := r.steps[id]; ok && step <= last {
+		return false, nil
+	}
+	r.steps[id] = step
+	return true, nil
+}
+
+// fakeRecoveryCodeRepo keeps the unused recovery code hashes of each account
+type fakeRecoveryCodeRepo struct {
+	repository.RecoveryCodeRepository
+
+	mu     sync.Mutex
+	unused map[uuid.UUID]map[string]bool
+}
+
+func newFakeRecoveryCodeRepo() *fakeRecoveryCodeRepo {
+	return &fakeRecoveryCodeRepo{unused: make(map[uuid.UUID]map[string]bool)}
+}
+
+func (r *fakeRecoveryCodeRepo) Replace(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		codes[hash] = true
+	}
+	r.unused[userID] = codes
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepo) Use(ctx context.Context, userID uuid.UUID, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.unused[userID][hash] {
+		return false, nil
+	}
+	delete(r.unused[userID], hash)
+	return true, nil
+}
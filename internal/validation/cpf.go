@@ -0,0 +1,66 @@
+package validation
+
+import "strings"
+
+// NormalizeCPF strips the punctuation from a CPF and validates its check
+// digits. It accepts both the formatted ("123.456.789-09") and the
+// unformatted ("12345678909") notation and returns the 11 digit form.
+func NormalizeCPF(cpf string) (string, bool) {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(cpf) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.' || r == '-' || r == ' ':
+			// separators are optional
+		default:
+			return "", false
+		}
+	}
+
+	digits := b.String()
+	if !IsValidCPF(digits) {
+		return "", false
+	}
+
+	return digits, true
+}
+
+// IsValidCPF reports whether digits is an 11 digit CPF with valid check digits
+func IsValidCPF(digits string) bool {
+	if len(digits) != 11 {
+		return false
+	}
+
+	allEqual := true
+	for i := 0; i < 11; i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return false
+		}
+		if digits[i] != digits[0] {
+			allEqual = false
+		}
+	}
+	// sequences like 111.111.111-11 pass the checksum but are not valid
+	if allEqual {
+		return false
+	}
+
+	return cpfCheckDigit(digits[:9]) == digits[9] && cpfCheckDigit(digits[:10]) == digits[10]
+}
+
+func cpfCheckDigit(digits string) byte {
+	sum := 0
+	weight := len(digits) + 1
+	for i := 0; i < len(digits); i++ {
+		sum += int(digits[i]-'0') * weight
+		weight--
+	}
+
+	rest := (sum * 10) % 11
+	if rest == 10 {
+		rest = 0
+	}
+
+	return byte('0' + rest)
+}
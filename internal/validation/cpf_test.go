@@ -0,0 +1,56 @@
+package validation
+
+import "testing"
+
+func TestNormalizeCPF(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		ok    bool
+	}{
+		{"formatted", "529.982.247-25", "52998224725", true},
+		{"unformatted", "52998224725", "52998224725", true},
+		{"surrounding spaces", "  111.444.777-35 ", "11144477735", true},
+		{"spaces as separators", "111 444 777 35", "11144477735", true},
+		{"check digit of zero", "123.456.789-09", "12345678909", true},
+		{"wrong first check digit", "529.982.247-15", "", false},
+		{"wrong second check digit", "529.982.247-24", "", false},
+		{"repeated digits", "111.111.111-11", "", false},
+		{"too short", "5299822472", "", false},
+		{"too long", "529982247250", "", false},
+		{"letters", "529.982.247-2a", "", false},
+		{"other separators", "529/982/247-25", "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NormalizeCPF(tt.input)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("NormalizeCPF(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestIsValidCPF(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   bool
+	}{
+		{"52998224725", true},
+		{"11144477735", true},
+		{"00000000000", false},
+		{"99999999999", false},
+		{"52998224726", false},
+		{"529.982.247", false},
+		{"5299822472x", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidCPF(tt.digits); got != tt.want {
+			t.Errorf("IsValidCPF(%q) = %v; want %v", tt.digits, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+)
+
+// Struct checks the `validate` tags of the fields of the struct v points
+// to and returns every violation as errors.ValidationErrors, naming the
+// fields after their json tag. Rules are separated by commas:
+//
+//	required   the field must be present and not blank
+//	email      a valid e-mail address
+//	uuid       a valid UUID
+//	date       a date in YYYY-MM-DD format
+//	min=N      at least N bytes, items or, for numbers, N
+//	max=N      at most N bytes, items or, for numbers, N
+//	oneof=a b  one of the space separated values
+//
+// Strings are measured in bytes, like the services and bcrypt do, so a
+// value passing max here can't be rejected later for its length. Rules
+// other than required are only checked on fields that were given.
+func Struct(v interface{}) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation.Struct: %T is not a struct", v))
+	}
+
+	var errs errors.ValidationErrors
+	for _, field := range fieldsOf(value.Type()) {
+		if err := field.check(value.Field(field.index)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+type structField struct {
+	index int
+	name  string
+	rules []rule
+}
+
+// fieldCache holds the parsed rules of every struct type seen so far
+var fieldCache sync.Map
+
+func fieldsOf(t reflect.Type) []structField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("validate")
+		if !ok || tag == "" {
+			continue
+		}
+
+		name := f.Name
+		if jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ","); jsonName != "" && jsonName != "-" {
+			name = jsonName
+		}
+
+		field := structField{index: i, name: name}
+		for _, spec := range strings.Split(tag, ",") {
+			ruleName, param, _ := strings.Cut(strings.TrimSpace(spec), "=")
+			if !knownRules[ruleName] {
+				panic(fmt.Sprintf("validation: unknown rule %q on %s.%s", ruleName, t.Name(), f.Name))
+			}
+			field.rules = append(field.rules, rule{name: ruleName, param: param})
+		}
+		fields = append(fields, field)
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+var knownRules = map[string]bool{
+	"required": true,
+	"email":    true,
+	"uuid":     true,
+	"date":     true,
+	"min":      true,
+	"max":      true,
+	"oneof":    true,
+}
+
+func (f structField) check(value reflect.Value) *errors.ValidationError {
+	present := isPresent(value)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	for _, r := range f.rules {
+		if r.name == "required" {
+			if !present {
+				return errors.NewValidationError(f.name, "is required")
+			}
+			continue
+		}
+		if !present {
+			continue
+		}
+
+		if message := checkRule(r, value); message != "" {
+			return errors.NewValidationError(f.name, message)
+		}
+	}
+
+	return nil
+}
+
+func isPresent(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return !value.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(value.String()) != ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() > 0
+	default:
+		return !value.IsZero()
+	}
+}
+
+// checkRule returns the message describing how value breaks r, or ""
+func checkRule(r rule, value reflect.Value) string {
+	switch r.name {
+	case "email":
+		s := strings.TrimSpace(value.String())
+		if _, err := mail.ParseAddress(s); err != nil || strings.ContainsAny(s, "<> ") {
+			return "must be a valid email address"
+		}
+	case "uuid":
+		if _, err := uuid.Parse(value.String()); err != nil {
+			return "must be a valid UUID"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value.String()); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	case "min", "max":
+		return checkBound(r, value)
+	case "oneof":
+		options := strings.Fields(r.param)
+		s := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if s == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	}
+
+	return ""
+}
+
+func checkBound(r rule, value reflect.Value) string {
+	bound, err := strconv.ParseInt(r.param, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: %s needs a number, got %q", r.name, r.param))
+	}
+
+	var actual int64
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		actual, unit = int64(len(value.String())), " bytes"
+	case reflect.Slice, reflect.Map:
+		actual, unit = int64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = int64(value.Uint())
+	default:
+		panic(fmt.Sprintf("validation: %s does not apply to %s", r.name, value.Type()))
+	}
+
+	if r.name == "min" && actual < bound {
+		if unit == "" {
+			return fmt.Sprintf("must be at least %d", bound)
+		}
+		return fmt.Sprintf("must have at least %d%s", bound, unit)
+	}
+	if r.name == "max" && actual > bound {
+		if unit == "" {
+			return fmt.Sprintf("must be at most %d", bound)
+		}
+		return fmt.Sprintf("must be at most %d%s", bound, unit)
+	}
+
+	return ""
+}
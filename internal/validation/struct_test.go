@@ -0,0 +1,142 @@
+package validation
+
+import (
+	stderrors "errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ruanv123/acme-hotel-api/internal/errors"
+)
+
+type sample struct {
+	Name     string   `json:"name" validate:"required,max=5"`
+	Email    string   `json:"email,omitempty" validate:"email"`
+	ID       string   `json:"id" validate:"uuid"`
+	Date     string   `json:"date" validate:"date"`
+	Status   string   `json:"status" validate:"oneof=open closed"`
+	Password string   `json:"password" validate:"min=3"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Count    *int     `json:"count" validate:"required,min=1,max=10"`
+	Untagged string   `validate:"max=1"`
+	Ignored  string   `json:"ignored"`
+}
+
+func valid() sample {
+	count := 1
+	return sample{Name: "Ana", Count: &count}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *sample)
+		want   map[string]string
+	}{
+		{"valid", func(s *sample) {}, nil},
+		{"required missing", func(s *sample) { s.Name = "" }, map[string]string{"name": "is required"}},
+		{"required blank", func(s *sample) { s.Name = "   " }, map[string]string{"name": "is required"}},
+		{"required nil pointer", func(s *sample) { s.Count = nil }, map[string]string{"count": "is required"}},
+		{"max string", func(s *sample) { s.Name = "Joanna" }, map[string]string{"name": "must be at most 5 bytes"}},
+		{"max counts bytes", func(s *sample) { s.Name = "Jõão" }, map[string]string{"name": "must be at most 5 bytes"}},
+		{"max fits", func(s *sample) { s.Name = "Jonas" }, nil},
+		{"min string", func(s *sample) { s.Password = "ab" }, map[string]string{"password": "must have at least 3 bytes"}},
+		{"max items", func(s *sample) { s.Tags = []string{"a", "b", "c"} }, map[string]string{"tags": "must be at most 2 items"}},
+		{"min number", func(s *sample) { zero := 0; s.Count = &zero }, map[string]string{"count": "must be at least 1"}},
+		{"max number", func(s *sample) { big := 11; s.Count = &big }, map[string]string{"count": "must be at most 10"}},
+		{"email", func(s *sample) { s.Email = "ana@example.com" }, nil},
+		{"invalid email", func(s *sample) { s.Email = "ana" }, map[string]string{"email": "must be a valid email address"}},
+		{"email with a display name", func(s *sample) { s.Email = "Ana <ana@example.com>" }, map[string]string{"email": "must be a valid email address"}},
+		{"uuid", func(s *sample) { s.ID = "9b2f1c1e-7c55-4b5e-9a53-4f7d3f3c2a10" }, nil},
+		{"invalid uuid", func(s *sample) { s.ID = "42" }, map[string]string{"id": "must be a valid UUID"}},
+		{"date", func(s *sample) { s.Date = "2024-02-29" }, nil},
+		{"invalid date", func(s *sample) { s.Date = "2023-02-29" }, map[string]string{"date": "must be a date in YYYY-MM-DD format"}},
+		{"date with time", func(s *sample) { s.Date = "2024-02-29T10:00:00Z" }, map[string]string{"date": "must be a date in YYYY-MM-DD format"}},
+		{"oneof", func(s *sample) { s.Status = "closed" }, nil},
+		{"not oneof", func(s *sample) { s.Status = "pending" }, map[string]string{"status": "must be one of: open, closed"}},
+		{"field named after the Go name without a json tag", func(s *sample) { s.Untagged = "ab" }, map[string]string{"Untagged": "must be at most 1 bytes"}},
+		{
+			"every violation is reported",
+			func(s *sample) { s.Name = ""; s.Email = "x"; s.Status = "x" },
+			map[string]string{"name": "is required", "email": "must be a valid email address", "status": "must be one of: open, closed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.modify(&s)
+
+			err := Struct(&s)
+			got := fieldErrors(t, err)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct returned %v; want %v", got, tt.want)
+			}
+			if err != nil && !stderrors.Is(err, errors.ErrInvalidInput) {
+				t.Errorf("error %v is not errors.ErrInvalidInput", err)
+			}
+		})
+	}
+}
+
+func TestStructReportsOneViolationPerField(t *testing.T) {
+	type request struct {
+		Code string `json:"code" validate:"min=3,oneof=abcd"`
+	}
+
+	got := fieldErrors(t, Struct(request{Code: "ab"}))
+	want := map[string]string{"code": "must have at least 3 bytes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Struct returned %v; want %v", got, want)
+	}
+}
+
+func TestStructPanicsOnMisuse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"not a struct", 42, "is not a struct"},
+		{"unknown rule", &struct {
+			A string `validate:"shiny"`
+		}{}, "unknown rule"},
+		{"bound without a number", &struct {
+			A string `validate:"max=many"`
+		}{A: "x"}, "needs a number"},
+		{"bound on an unsupported type", &struct {
+			A bool `validate:"max=1"`
+		}{A: true}, "does not apply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(r.(string), tt.want) {
+					t.Errorf("panic = %v; want one containing %q", r, tt.want)
+				}
+			}()
+			Struct(tt.value)
+		})
+	}
+}
+
+// fieldErrors flattens the error returned by Struct into field -> message
+func fieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+
+	var errs errors.ValidationErrors
+	if !stderrors.As(err, &errs) {
+		t.Fatalf("Struct returned %T, not errors.ValidationErrors", err)
+	}
+
+	fields := make(map[string]string, len(errs))
+	for _, e := range errs {
+		fields[e.Field] = e.Message
+	}
+	return fields
+}